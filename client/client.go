@@ -0,0 +1,489 @@
+// Package client is a typed Go client for the Ceptor Club game server. It
+// follows the contract in openapi.json, so bots and tests can call the API
+// without hand-building form posts.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Player struct {
+	WalletAddress  string
+	PlayerName     string
+	GameTokens     int
+	ArtTokens      int
+	TechTokens     int
+	ArtXP          int
+	GameXP         int
+	TechXP         int
+	RiddleAttempts map[string]RiddleAttempt // By riddle ID
+	RiddleScore    int
+	Role           string
+	Visited        map[string]bool
+	Badges         map[string]time.Time // Achievement ID to when it was unlocked
+	Notifications  []string
+	Removal        *Removal // Set while the player is in Purgatory
+}
+
+type RiddleAttempt struct {
+	Tries   int
+	LastTry time.Time
+	Solved  bool
+	Hints   int // Hints bought so far
+}
+
+type Removal struct {
+	RemovedBy string
+	RemovedAt time.Time
+	Reason    string
+	Appeal    string
+}
+
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	On          []string
+}
+
+type Progress struct {
+	Level       int
+	XP          int
+	LevelXP     int
+	NextLevelXP int // 0 at the top level
+	XPToNext    int
+}
+
+type Status struct {
+	Player
+	Levels map[string]Progress // Keyed by track: "art", "game", "tech", "club"
+}
+
+type Location struct {
+	Name        string
+	Description string
+	Challenge   string
+}
+
+type Scenario struct {
+	Location  string
+	Challenge string
+}
+
+type Adventure struct {
+	Name        string
+	Description string
+	Scenarios   map[string]Scenario
+}
+
+type Character struct {
+	Name               string
+	ClassAllocation    map[string]int
+	Background         string
+	Abilities          map[string]int
+	EffectiveAbilities map[string]int
+	Skills             []string
+	Features           map[string][]string
+	Equipment          []string
+	Spells             map[string][]string
+	Debuffs            map[string]int
+}
+
+type LoginResponse struct {
+	Token         string
+	WalletAddress string
+	Role          string
+}
+
+type Award struct {
+	GameTokens int
+	ArtTokens  int
+	TechTokens int
+	ArtXP      int
+	GameXP     int
+	TechXP     int
+	Reason     string `json:",omitempty"` // Required when any amount is negative
+}
+
+type LedgerEntry struct {
+	Actor         string
+	WalletAddress string
+	GameTokens    int
+	ArtTokens     int
+	TechTokens    int
+	ArtXP         int
+	GameXP        int
+	TechXP        int
+	Reason        string
+	Time          time.Time
+}
+
+type Transfer struct {
+	To     string
+	Token  string // "game", "art" or "tech"
+	Amount int
+}
+
+type OfferRequest struct {
+	Give       string
+	GiveAmount int
+	Want       string
+	WantAmount int
+}
+
+type Offer struct {
+	ID         int
+	Seller     string
+	Give       string
+	GiveAmount int
+	Want       string
+	WantAmount int
+	Status     string
+	Buyer      string
+	CreatedAt  time.Time
+	ClosedAt   time.Time
+}
+
+type LeaderboardRow struct {
+	Rank          int
+	WalletAddress string
+	PlayerName    string
+	Score         int
+}
+
+type LeaderboardPage struct {
+	Metric   string
+	Period   string
+	Page     int
+	PageSize int
+	Total    int
+	Rows     []LeaderboardRow
+}
+
+type Riddle struct {
+	ID           string
+	Language     string
+	Difficulty   string // easy, medium or hard
+	Prompt       string
+	Code         string
+	CodeAnswer   bool // Answered with Go code, run against hidden tests
+	Attempted    bool
+	Solved       bool
+	Locked       bool // Solved or out of attempts
+	Tries        int
+	MaxAttempts  int        // 0 means unlimited
+	AttemptsLeft int        // -1 means unlimited
+	RetryAt      *time.Time `json:",omitempty"` // Set during the cooldown after a wrong answer
+	Reward       Balances   // What a right answer pays now
+	Hints        []string   // Bought so far
+	HintsLeft    int
+	HintCost     Balances
+}
+
+type RiddleResult struct {
+	ID           string
+	Language     string
+	Correct      bool
+	Message      string
+	Locked       bool     // No more attempts allowed
+	Tries        int      // Including this one
+	AttemptsLeft int      // -1 means unlimited
+	Paid         Balances // The reward, once solved
+	Output       string   `json:",omitempty"` // Why a code answer failed
+}
+
+type Balances struct {
+	GameTokens int
+	ArtTokens  int
+	TechTokens int
+	ArtXP      int
+	GameXP     int
+	TechXP     int
+}
+
+type RiddleHints struct {
+	ID    string
+	Hints []string // Every hint bought so far, the new one last
+}
+
+// Error is returned for any non-2xx response.
+type Error struct {
+	StatusCode int
+	Code       string `json:"Error"`
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("ceptor: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Client talks to one game server. After Login it sends the session token
+// with every request.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Token      string
+}
+
+// New returns a client for the server at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Nonce fetches the one-time message the wallet must sign to log in.
+func (c *Client) Nonce(ctx context.Context, wallet string) (string, error) {
+	var resp struct {
+		Message string
+	}
+	err := c.do(ctx, "GET", "/login/nonce?wallet="+url.QueryEscape(wallet), nil, &resp)
+	return resp.Message, err
+}
+
+// Login exchanges a signature over the nonce message for a session.
+func (c *Client) Login(ctx context.Context, wallet, signature string) (*LoginResponse, error) {
+	form := url.Values{"wallet": {wallet}, "signature": {signature}}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var resp LoginResponse
+	if err := c.send(req, &resp); err != nil {
+		return nil, err
+	}
+	c.Token = resp.Token
+	return &resp, nil
+}
+
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.do(ctx, "POST", "/logout", nil, nil)
+	c.Token = ""
+	return err
+}
+
+// Status returns the logged in player and their levels.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	return &status, c.do(ctx, "GET", "/status", nil, &status)
+}
+
+func (c *Client) ListPlayers(ctx context.Context) ([]Player, error) {
+	var players []Player
+	return players, c.do(ctx, "GET", "/players", nil, &players)
+}
+
+func (c *Client) AddPlayer(ctx context.Context, wallet, name string) (*Player, error) {
+	var player Player
+	body := map[string]string{"WalletAddress": wallet, "PlayerName": name}
+	return &player, c.do(ctx, "POST", "/players", body, &player)
+}
+
+func (c *Client) CheckPlayer(ctx context.Context, wallet string) (*Player, error) {
+	var player Player
+	return &player, c.do(ctx, "GET", "/players/"+url.PathEscape(wallet), nil, &player)
+}
+
+// RemovePlayer moves the player to Purgatory. reason may be empty.
+func (c *Client) RemovePlayer(ctx context.Context, wallet, reason string) error {
+	path := "/players/" + url.PathEscape(wallet)
+	if reason != "" {
+		path += "?reason=" + url.QueryEscape(reason)
+	}
+	return c.do(ctx, "DELETE", path, nil, nil)
+}
+
+// Purgatory lists the removed players, longest removed first.
+func (c *Client) Purgatory(ctx context.Context) ([]Player, error) {
+	var players []Player
+	return players, c.do(ctx, "GET", "/purgatory", nil, &players)
+}
+
+func (c *Client) RestorePlayer(ctx context.Context, wallet string) (*Player, error) {
+	var player Player
+	return &player, c.do(ctx, "POST", "/purgatory/"+url.PathEscape(wallet)+"/restore", nil, &player)
+}
+
+func (c *Client) AppealRemoval(ctx context.Context, wallet, appeal string) error {
+	return c.do(ctx, "PUT", "/purgatory/"+url.PathEscape(wallet)+"/appeal", map[string]string{"Appeal": appeal}, nil)
+}
+
+func (c *Client) Award(ctx context.Context, wallet string, award Award) (*Player, error) {
+	var player Player
+	return &player, c.do(ctx, "POST", "/players/"+url.PathEscape(wallet)+"/awards", award, &player)
+}
+
+// Ledger returns every change to the player's tokens and XP, oldest first.
+func (c *Client) Ledger(ctx context.Context, wallet string) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+	return entries, c.do(ctx, "GET", "/players/"+url.PathEscape(wallet)+"/ledger", nil, &entries)
+}
+
+// Transfer sends tokens from the logged in player and returns the sender afterwards.
+func (c *Client) Transfer(ctx context.Context, transfer Transfer) (*Player, error) {
+	var player Player
+	return &player, c.do(ctx, "POST", "/transfers", transfer, &player)
+}
+
+func (c *Client) Offers(ctx context.Context) ([]Offer, error) {
+	var offers []Offer
+	return offers, c.do(ctx, "GET", "/offers", nil, &offers)
+}
+
+func (c *Client) PostOffer(ctx context.Context, offer OfferRequest) (*Offer, error) {
+	var posted Offer
+	return &posted, c.do(ctx, "POST", "/offers", offer, &posted)
+}
+
+func (c *Client) AcceptOffer(ctx context.Context, id int) (*Offer, error) {
+	var offer Offer
+	return &offer, c.do(ctx, "POST", fmt.Sprintf("/offers/%d/accept", id), nil, &offer)
+}
+
+func (c *Client) CancelOffer(ctx context.Context, id int) (*Offer, error) {
+	var offer Offer
+	return &offer, c.do(ctx, "DELETE", fmt.Sprintf("/offers/%d", id), nil, &offer)
+}
+
+func (c *Client) SetRole(ctx context.Context, wallet, role string) (*Player, error) {
+	var player Player
+	body := map[string]string{"Role": role}
+	return &player, c.do(ctx, "PUT", "/players/"+url.PathEscape(wallet)+"/role", body, &player)
+}
+
+func (c *Client) AllowList(ctx context.Context) ([]string, error) {
+	var wallets []string
+	return wallets, c.do(ctx, "GET", "/allowlist", nil, &wallets)
+}
+
+// Leaderboard fetches one page of a leaderboard. metric is one of "gamexp",
+// "artxp", "techxp", "tokens" or "riddles"; period is "alltime" or "weekly".
+// Pages start at 1; zero values use the server's defaults.
+func (c *Client) Leaderboard(ctx context.Context, metric, period string, page, pageSize int) (*LeaderboardPage, error) {
+	query := url.Values{"metric": {metric}}
+	if period != "" {
+		query.Set("period", period)
+	}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if pageSize > 0 {
+		query.Set("pageSize", strconv.Itoa(pageSize))
+	}
+	var resp LeaderboardPage
+	return &resp, c.do(ctx, "GET", "/leaderboard?"+query.Encode(), nil, &resp)
+}
+
+func (c *Client) Save(ctx context.Context, filename string) error {
+	return c.do(ctx, "POST", "/save", map[string]string{"Filename": filename}, nil)
+}
+
+func (c *Client) Load(ctx context.Context, filename string) error {
+	return c.do(ctx, "POST", "/load", map[string]string{"Filename": filename}, nil)
+}
+
+func (c *Client) Locations(ctx context.Context) ([]Location, error) {
+	var locations []Location
+	return locations, c.do(ctx, "GET", "/locations", nil, &locations)
+}
+
+func (c *Client) Location(ctx context.Context, name string) (*Location, error) {
+	var location Location
+	return &location, c.do(ctx, "GET", "/locations/"+url.PathEscape(name), nil, &location)
+}
+
+func (c *Client) Adventure(ctx context.Context) (*Adventure, error) {
+	var adventure Adventure
+	return &adventure, c.do(ctx, "GET", "/adventure", nil, &adventure)
+}
+
+func (c *Client) Characters(ctx context.Context) ([]Character, error) {
+	var characters []Character
+	return characters, c.do(ctx, "GET", "/characters", nil, &characters)
+}
+
+func (c *Client) Achievements(ctx context.Context) ([]Achievement, error) {
+	var achievements []Achievement
+	return achievements, c.do(ctx, "GET", "/achievements", nil, &achievements)
+}
+
+// Notifications returns the logged in player's unread notifications and marks them read.
+func (c *Client) Notifications(ctx context.Context) ([]string, error) {
+	var notifications []string
+	return notifications, c.do(ctx, "GET", "/notifications", nil, &notifications)
+}
+
+// Riddles returns the riddles the logged in player can still answer.
+func (c *Client) Riddles(ctx context.Context) ([]Riddle, error) {
+	var riddles []Riddle
+	return riddles, c.do(ctx, "GET", "/riddles", nil, &riddles)
+}
+
+// Riddle returns one riddle and the logged in player's attempts at it.
+func (c *Client) Riddle(ctx context.Context, id string) (*Riddle, error) {
+	var riddle Riddle
+	return &riddle, c.do(ctx, "GET", "/riddles/"+url.PathEscape(id), nil, &riddle)
+}
+
+func (c *Client) AnswerRiddle(ctx context.Context, id, answer string) (*RiddleResult, error) {
+	var result RiddleResult
+	body := map[string]string{"Answer": answer}
+	return &result, c.do(ctx, "POST", "/riddles/"+url.PathEscape(id)+"/answer", body, &result)
+}
+
+// RiddleHint buys the next hint for a riddle, charging the riddle's hint cost.
+func (c *Client) RiddleHint(ctx context.Context, id string) (*RiddleHints, error) {
+	var hints RiddleHints
+	return &hints, c.do(ctx, "POST", "/riddles/"+url.PathEscape(id)+"/hint", nil, &hints)
+}
+
+// do sends body as JSON (when non-nil) and decodes the response into out (when non-nil).
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+func (c *Client) send(req *http.Request, out interface{}) error {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		if err := json.NewDecoder(resp.Body).Decode(apiErr); err != nil {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return apiErr
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
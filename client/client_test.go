@@ -0,0 +1,152 @@
+package client_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/tippi-fifestarr/go-ceptor/client"
+	"github.com/tippi-fifestarr/go-ceptor/game"
+	"github.com/tippi-fifestarr/go-ceptor/server"
+)
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// loginAs adds a player with a new wallet and logs c in as them, signing
+// the server's challenge.
+func loginAs(t *testing.T, g *game.Game, c *client.Client, name string) string {
+	t.Helper()
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := "0x" + hex.EncodeToString(keccak256(key.PubKey().SerializeUncompressed()[1:])[12:])
+	if err := g.AddPlayer(wallet, name); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	message, err := c.Nonce(ctx, wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), []byte(message))
+	compact := ecdsa.SignCompact(key, hash, false)
+	signature := "0x" + hex.EncodeToString(append(compact[1:], compact[0]))
+	if _, err := c.Login(ctx, wallet, signature); err != nil {
+		t.Fatal(err)
+	}
+	return wallet
+}
+
+func TestClientRoundTrip(t *testing.T) {
+	g := game.New()
+	srv := httptest.NewServer(server.NewMux(g, game.NewSessionStore(), game.NewChallengeStore()))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := client.New(srv.URL)
+	var apiErr *client.Error
+	if _, err := c.Status(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status before login: err = %v", err)
+	}
+	wallet := loginAs(t, g, c, "Admin")
+	if err := g.SetRole(wallet, game.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := c.Status(ctx)
+	if err != nil || status.WalletAddress != wallet || status.Role != string(game.RoleAdmin) {
+		t.Fatalf("status = %+v, %v", status, err)
+	}
+
+	player, err := c.AddPlayer(ctx, "0xFriend", "Friend")
+	if err != nil || player.WalletAddress != "0xFriend" {
+		t.Fatalf("add player = %+v, %v", player, err)
+	}
+	player, err = c.Award(ctx, wallet, client.Award{GameTokens: 20, ArtXP: 5, Reason: "testing"})
+	if err != nil || player.GameTokens != status.GameTokens+20 || player.ArtXP != 5 {
+		t.Fatalf("award = %+v, %v", player, err)
+	}
+	entries, err := c.Ledger(ctx, wallet)
+	if err != nil || len(entries) == 0 || entries[len(entries)-1].Reason == "" {
+		t.Fatalf("ledger = %+v, %v", entries, err)
+	}
+	if _, err := c.Transfer(ctx, client.Transfer{To: "0xFriend", Token: "game", Amount: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	offer, err := c.PostOffer(ctx, client.OfferRequest{Give: "game", GiveAmount: 2, Want: "art", WantAmount: 1})
+	if err != nil || offer.ID == 0 || offer.Seller != wallet {
+		t.Fatalf("post offer = %+v, %v", offer, err)
+	}
+	offers, err := c.Offers(ctx)
+	if err != nil || len(offers) != 1 {
+		t.Fatalf("offers = %+v, %v", offers, err)
+	}
+	if offer, err = c.CancelOffer(ctx, offer.ID); err != nil || offer.Status != "cancelled" {
+		t.Fatalf("cancel offer = %+v, %v", offer, err)
+	}
+
+	if player, err = c.SetRole(ctx, "0xFriend", "gamemaster"); err != nil || player.Role != "gamemaster" {
+		t.Fatalf("set role = %+v, %v", player, err)
+	}
+	if err := c.RemovePlayer(ctx, "0xFriend", "testing"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.AppealRemoval(ctx, "0xFriend", "please"); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := c.Purgatory(ctx)
+	if err != nil || len(removed) != 1 || removed[0].Removal == nil || removed[0].Removal.Appeal != "please" {
+		t.Fatalf("purgatory = %+v, %v", removed, err)
+	}
+	if _, err := c.RestorePlayer(ctx, "0xFriend"); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := c.Leaderboard(ctx, "tokens", "", 1, 2)
+	if err != nil || page.PageSize != 2 || len(page.Rows) == 0 {
+		t.Fatalf("leaderboard = %+v, %v", page, err)
+	}
+
+	riddle, err := c.Riddle(ctx, "go")
+	if err != nil || riddle.ID != "go" || riddle.Locked {
+		t.Fatalf("riddle = %+v, %v", riddle, err)
+	}
+	hints, err := c.RiddleHint(ctx, "go")
+	if err != nil || len(hints.Hints) != 1 {
+		t.Fatalf("hint = %+v, %v", hints, err)
+	}
+	result, err := c.AnswerRiddle(ctx, "go", ":=")
+	if err != nil || !result.Correct || result.Paid == (client.Balances{}) {
+		t.Fatalf("answer = %+v, %v", result, err)
+	}
+
+	if locations, err := c.Locations(ctx); err != nil || len(locations) == 0 {
+		t.Fatalf("locations = %+v, %v", locations, err)
+	}
+	if _, err := c.CheckPlayer(ctx, "0xNobody"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "not_found" {
+		t.Errorf("unknown player: err = %v", err)
+	}
+
+	if err := c.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Status(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status after logout: err = %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Command ceptor-cli runs the interactive REPL and, unless -no-server is
+// given, the HTTP game server next to it. Both share one game.
+//
+// "ceptor-cli [flags] run [-as wallet] [-dry-run] [script]" runs REPL
+// commands from a script, or from stdin, instead. It stops at the first
+// command that fails and exits with status 1, or 2 if the script could
+// not be run at all.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+	"github.com/tippi-fifestarr/go-ceptor/internal/setup"
+	"github.com/tippi-fifestarr/go-ceptor/repl"
+	"github.com/tippi-fifestarr/go-ceptor/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address for the HTTP server to listen on")
+	noServer := flag.Bool("no-server", false, "run only the interactive REPL, without the HTTP server")
+	shared := setup.Register(flag.CommandLine)
+	historyFile := flag.String("history", defaultHistoryFile(), "file to keep the REPL's command history in between sessions (empty: keep none)")
+	flag.Parse()
+
+	// Both front ends share one *Game and one set of login challenges.
+	g, err := shared.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer g.Close()
+	challenges := game.NewChallengeStore()
+
+	if flag.Arg(0) == "run" {
+		os.Exit(runScript(g, challenges, flag.Args()[1:]))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shared.AutoPurge(ctx, g)
+
+	done := make(chan struct{})
+	if !*noServer {
+		sessions := game.NewSessionStore()
+		g.SetSessionStore(sessions) // Players removed at the prompt are logged out of the API too
+		go func() {
+			defer close(done)
+			if err := server.ListenAndServe(ctx, *addr, server.NewMux(g, sessions, challenges)); err != nil {
+				log.Println("Server error:", err)
+				stop()
+			}
+		}()
+	} else {
+		close(done)
+	}
+	go func() {
+		repl.Run(g, challenges, os.Stdin, repl.Options{HistoryFile: *historyFile})
+		stop() // "exit" shuts the server down too
+	}()
+
+	<-ctx.Done()
+	<-done
+}
+
+// runScript is the "run" subcommand. It returns the exit status, and
+// closes g itself because os.Exit skips main's deferred calls.
+func runScript(g *game.Game, challenges *game.ChallengeStore, args []string) int {
+	defer g.Close()
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	as := flags.String("as", "", "run the script logged in as this wallet address")
+	dryRun := flags.Bool("dry-run", false, "report what the script would change without changing anything")
+	flags.Parse(args)
+
+	var script io.Reader = os.Stdin
+	name := "stdin"
+	if path := flags.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Println(err)
+			return 2
+		}
+		defer f.Close()
+		script, name = f, path
+	}
+	err := repl.RunScript(g, challenges, script, name, repl.BatchOptions{As: *as, DryRun: *dryRun})
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, repl.ErrCommandFailed):
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	default:
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+}
+
+// defaultHistoryFile is ~/.ceptor_history, or nothing without a home directory.
+func defaultHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ceptor_history")
+}
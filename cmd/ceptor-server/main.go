@@ -0,0 +1,38 @@
+// Command ceptor-server runs the HTTP game server on its own, without the REPL.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+	"github.com/tippi-fifestarr/go-ceptor/internal/setup"
+	"github.com/tippi-fifestarr/go-ceptor/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address for the HTTP server to listen on")
+	shared := setup.Register(flag.CommandLine)
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g, err := shared.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer g.Close()
+	shared.AutoPurge(ctx, g)
+
+	sessions := game.NewSessionStore()
+	g.SetSessionStore(sessions)
+	handler := server.NewMux(g, sessions, game.NewChallengeStore())
+	if err := server.ListenAndServe(ctx, *addr, handler); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,45 @@
+// Command character-picker shows one of Tippi's pregenerated characters.
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tippi-fifestarr/go-ceptor/content"
+)
+
+func main() {
+	fmt.Println("Welcome to Tippi's choices. Please enter a number or the first word of the name to choose:")
+	fmt.Println("1. Savage Guardian")
+	fmt.Println("2. Technomage Rebel")
+	fmt.Println("3. Cosmic Protector")
+	fmt.Println("4. Elemental Warden")
+	fmt.Println("5. Arcane Reclaimer")
+	fmt.Println("6. Natures Vanguard")
+
+	var choice string
+	fmt.Scanln(&choice)
+
+	switch strings.ToLower(choice) {
+	case "1", "savage":
+		savageGuardian := content.NewSavageGuardian()
+		savageGuardian.Display()
+	case "2", "technomage":
+		technomageUprising := content.NewTechnomageUprising()
+		technomageUprising.Display()
+	case "3", "cosmic":
+		cosmicProtector := content.NewCosmicProtector()
+		cosmicProtector.Display()
+	case "4", "elemental":
+		elementalWarden := content.NewElementalWarden()
+		elementalWarden.Display()
+	case "5", "arcane":
+		arcaneReclaimer := content.NewArcaneReclaimer()
+		arcaneReclaimer.Display()
+	case "6", "natures":
+		naturesVanguard := content.NewNaturesVanguard()
+		naturesVanguard.Display()
+	default:
+		fmt.Println("Invalid choice. Please try again.")
+	}
+}
@@ -1,5 +1,5 @@
 // Description: This file contains the Adventure and Scenario structs, as well as the MainAdventure variable that holds the main adventure's data.
-package main
+package content
 
 type Adventure struct {
 	Name        string
@@ -68,4 +68,5 @@ var MainAdventure = Adventure{
 			Location:  "Quantum Caves",
 			Challenge: "The caves are a labyrinth of shifting realities, concealing a portal to the Digitizers' homeworld.",
 		},
-	},
\ No newline at end of file
+	},
+}
@@ -1,9 +1,6 @@
-package main
+package content
 
-import (
-	"fmt"
-	"strings"
-)
+import "fmt"
 
 type Character struct {
 	Name               string
@@ -318,38 +315,14 @@ func NewNaturesVanguard() *Character {
 	return character
 }
 
-func main() {
-	fmt.Println("Welcome to Tippi's choices. Please enter a number or the first word of the name to choose:")
-	fmt.Println("1. Savage Guardian")
-	fmt.Println("2. Technomage Rebel")
-	fmt.Println("3. Cosmic Protector")
-	fmt.Println("4. Elemental Warden")
-	fmt.Println("5. Arcane Reclaimer")
-	fmt.Println("6. Natures Vanguard")
-
-	var choice string
-	fmt.Scanln(&choice)
-
-	switch strings.ToLower(choice) {
-	case "1", "savage":
-		savageGuardian := NewSavageGuardian()
-		savageGuardian.Display()
-	case "2", "technomage":
-		technomageUprising := NewTechnomageUprising()
-		technomageUprising.Display()
-	case "3", "cosmic":
-		cosmicProtector := NewCosmicProtector()
-		cosmicProtector.Display()
-	case "4", "elemental":
-		elementalWarden := NewElementalWarden()
-		elementalWarden.Display()
-	case "5", "arcane":
-		arcaneReclaimer := NewArcaneReclaimer()
-		arcaneReclaimer.Display()
-	case "6", "natures":
-		naturesVanguard := NewNaturesVanguard()
-		naturesVanguard.Display()
-	default:
-		fmt.Println("Invalid choice. Please try again.")
+// PregeneratedCharacters returns Tippi's six pregenerated builds, in menu order.
+func PregeneratedCharacters() []*Character {
+	return []*Character{
+		NewSavageGuardian(),
+		NewTechnomageUprising(),
+		NewCosmicProtector(),
+		NewElementalWarden(),
+		NewArcaneReclaimer(),
+		NewNaturesVanguard(),
 	}
 }
@@ -1,5 +1,5 @@
 // Description: This file contains the definition of the Location struct and a map of Location objects representing the different locations in the game. Each Location object has a name, description, and challenge associated with it. The map is used to store and access the Location objects by their names.
-package main
+package content
 
 type Location struct {
 	Name string
@@ -0,0 +1,191 @@
+// Description: This file contains the achievement engine. Game actions fire events; every achievement that listens to the event checks the player and, the first time it passes, pins a badge on them and leaves a notification for them to read.
+
+package game
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tippi-fifestarr/go-ceptor/content"
+)
+
+type EventKind string
+
+const (
+	EventLogin   EventKind = "login"   // The player logged in
+	EventRiddle  EventKind = "riddle"  // The player answered a riddle
+	EventVisit   EventKind = "visit"   // The player read a location
+	EventBalance EventKind = "balance" // The player's tokens or XP changed
+)
+
+// Achievement is a rule that unlocks a badge. It is only checked when one
+// of the events in On happens to the player.
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	On          []EventKind
+	unlocked    func(g *Game, p *Player) bool // The caller holds g.mu
+}
+
+// Achievements are checked in this order, so badges unlocked by the same
+// event are announced in this order too.
+var Achievements = []Achievement{
+	{
+		ID:          "first-login",
+		Name:        "Hop In",
+		Description: "Log in for the first time",
+		On:          []EventKind{EventLogin},
+		unlocked:    func(g *Game, p *Player) bool { return true },
+	},
+	{
+		ID:          "first-riddle",
+		Name:        "Riddler",
+		Description: "Solve a riddle",
+		On:          []EventKind{EventRiddle},
+		unlocked:    func(g *Game, p *Player) bool { return p.RiddleScore > 0 },
+	},
+	{
+		ID:          "all-riddles",
+		Name:        "Riddle Master",
+		Description: "Solve every riddle",
+		On:          []EventKind{EventRiddle},
+		unlocked: func(g *Game, p *Player) bool {
+			for _, id := range g.riddleBank().IDs() {
+				if !p.RiddleAttempts[id].Solved {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		ID:          "explorer",
+		Name:        "Multiverse Explorer",
+		Description: "Visit every location",
+		On:          []EventKind{EventVisit},
+		unlocked: func(g *Game, p *Player) bool {
+			for name := range content.Locations {
+				if !p.Visited[name] {
+					return false
+				}
+			}
+			return true
+		},
+	},
+	{
+		ID:          "art-1000",
+		Name:        "Art Virtuoso",
+		Description: "Reach 1000 Art XP",
+		On:          []EventKind{EventBalance},
+		unlocked:    func(g *Game, p *Player) bool { return p.ArtXP >= 1000 },
+	},
+	{
+		ID:          "game-1000",
+		Name:        "Game Sage",
+		Description: "Reach 1000 Game XP",
+		On:          []EventKind{EventBalance},
+		unlocked:    func(g *Game, p *Player) bool { return p.GameXP >= 1000 },
+	},
+	{
+		ID:          "tech-1000",
+		Name:        "Tech Wizard",
+		Description: "Reach 1000 Tech XP",
+		On:          []EventKind{EventBalance},
+		unlocked:    func(g *Game, p *Player) bool { return p.TechXP >= 1000 },
+	},
+}
+
+var ErrUnknownLocation = errors.New("location not found")
+
+// AchievementByID looks up an achievement, e.g. to name a badge.
+func AchievementByID(id string) (Achievement, bool) {
+	for _, achievement := range Achievements {
+		if achievement.ID == id {
+			return achievement, true
+		}
+	}
+	return Achievement{}, false
+}
+
+func (a Achievement) listensTo(kind EventKind) bool {
+	for _, on := range a.On {
+		if on == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitLocation records that the player has read the location.
+func (g *Game) VisitLocation(walletAddress, name string) error {
+	if _, ok := content.Locations[name]; !ok {
+		return ErrUnknownLocation
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	err := g.updatePlayer(walletAddress, func(player *Player) {
+		if player.Visited == nil {
+			player.Visited = make(map[string]bool)
+		}
+		player.Visited[name] = true
+	})
+	if err != nil {
+		return err
+	}
+	return g.fire(EventVisit, walletAddress)
+}
+
+// TakeNotifications returns the player's unread notifications and marks
+// them read.
+func (g *Game) TakeNotifications(walletAddress string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return nil, ErrPlayerNotFound
+	}
+	notifications := player.Notifications
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+	err := g.updatePlayer(walletAddress, func(player *Player) { player.Notifications = nil })
+	if err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// fire checks every achievement listening to the event and awards the
+// badges the player has newly earned. Unknown wallets are ignored. The
+// caller must hold g.mu.
+func (g *Game) fire(kind EventKind, walletAddress string) error {
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return nil
+	}
+	var earned []Achievement
+	for _, achievement := range Achievements {
+		if _, has := player.Badges[achievement.ID]; has || !achievement.listensTo(kind) {
+			continue
+		}
+		if achievement.unlocked(g, player) {
+			earned = append(earned, achievement)
+		}
+	}
+	if len(earned) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	return g.updatePlayer(walletAddress, func(player *Player) {
+		if player.Badges == nil {
+			player.Badges = make(map[string]time.Time)
+		}
+		for _, achievement := range earned {
+			player.Badges[achievement.ID] = now
+			player.Notifications = append(player.Notifications,
+				fmt.Sprintf("Badge unlocked: %s - %s", achievement.Name, achievement.Description))
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package game
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tippi-fifestarr/go-ceptor/content"
+)
+
+func TestAchievements(t *testing.T) {
+	g := New()
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	hasBadge := func(id string) bool {
+		player, _ := g.Player("0xNew")
+		_, ok := player.Badges[id]
+		return ok
+	}
+
+	if !g.Login("0xNew") || !hasBadge("first-login") {
+		t.Error("first login did not unlock first-login")
+	}
+	notifications, err := g.TakeNotifications("0xNew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 1 || notifications[0] != "Badge unlocked: Hop In - Log in for the first time" {
+		t.Errorf("notifications = %q", notifications)
+	}
+	g.Login("0xNew")
+	if again, _ := g.TakeNotifications("0xNew"); len(again) != 0 {
+		t.Errorf("second login notified again: %q", again)
+	}
+
+	for name := range content.Locations {
+		if hasBadge("explorer") {
+			t.Fatal("explorer unlocked before every location was visited")
+		}
+		if err := g.VisitLocation("0xNew", name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !hasBadge("explorer") {
+		t.Error("visiting every location did not unlock explorer")
+	}
+	if err := g.VisitLocation("0xNew", "Atlantis"); !errors.Is(err, ErrUnknownLocation) {
+		t.Errorf("unknown location: err = %v", err)
+	}
+
+	for _, id := range g.RiddleBank().IDs() {
+		if err := g.RecordRiddle("0xNew", id, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !hasBadge("first-riddle") || !hasBadge("all-riddles") {
+		t.Error("solving every riddle did not unlock first-riddle and all-riddles")
+	}
+
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{TechXP: 1000}, "shipped it"); err != nil {
+		t.Fatal(err)
+	}
+	if !hasBadge("tech-1000") || hasBadge("art-1000") {
+		t.Error("1000 Tech XP should unlock tech-1000 and nothing else")
+	}
+}
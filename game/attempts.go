@@ -0,0 +1,298 @@
+// Description: This file contains the riddle attempt model. Every answer counts as a try and is timestamped; a riddle's policy decides how many tries a player gets, how long they wait after a wrong answer, what hints cost and how much a late right answer still pays.
+
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RiddleAttempt is a player's record on one riddle.
+type RiddleAttempt struct {
+	Tries   int       // Answers given, right or wrong
+	LastTry time.Time // When the last answer was given; zero in saves from before tries were timed
+	Solved  bool
+	Hints   int // Hints revealed so far
+}
+
+// UnmarshalJSON also reads the bare true or false that players stored
+// before attempts were counted: a riddle solved, or failed once for good.
+// Saves are migrated, but players kept in a SQLite store are not.
+func (a *RiddleAttempt) UnmarshalJSON(data []byte) error {
+	var solved bool
+	if err := json.Unmarshal(data, &solved); err == nil {
+		*a = RiddleAttempt{Tries: 1, Solved: solved}
+		return nil
+	}
+	type plain RiddleAttempt // Without this method
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+type RiddleResult struct {
+	ID           string
+	Language     string
+	Correct      bool
+	Message      string
+	Locked       bool     // No more attempts allowed
+	Tries        int      // Including this one
+	AttemptsLeft int      // -1 means unlimited
+	Paid         Balances // The reward, once solved
+	Output       string   `json:",omitempty"` // Why a code answer failed
+}
+
+var (
+	ErrRiddleAttempted = errors.New("you've already solved this riddle or used up your attempts")
+	ErrTopicExhausted  = errors.New("you've already solved or used up every riddle on this topic")
+	ErrRiddleCooldown  = errors.New("too soon to answer this riddle again")
+	ErrNoMoreHints     = errors.New("there are no more hints for this riddle")
+)
+
+// Locked reports whether the attempt leaves no more tries: the riddle is
+// solved, or every allowed try has been used.
+func (r Riddle) Locked(a RiddleAttempt) bool {
+	return a.Solved || (r.MaxAttempts > 0 && a.Tries >= r.MaxAttempts)
+}
+
+// AttemptsLeft is how many more answers the player may give, or -1 when
+// the riddle allows unlimited tries.
+func (r Riddle) AttemptsLeft(a RiddleAttempt) int {
+	switch {
+	case a.Solved:
+		return 0
+	case r.MaxAttempts == 0:
+		return -1
+	}
+	return max(0, r.MaxAttempts-a.Tries)
+}
+
+// RetryAt is when the player may answer again after a wrong answer. It is
+// zero when there is nothing to wait for.
+func (r Riddle) RetryAt(a RiddleAttempt) time.Time {
+	if a.Tries == 0 || a.Solved || r.Cooldown == 0 || a.LastTry.IsZero() {
+		return time.Time{}
+	}
+	return a.LastTry.Add(r.Cooldown)
+}
+
+// RevealedHints is the hints the player has bought. A bank swapped in
+// since may have fewer hints than they bought.
+func (r Riddle) RevealedHints(a RiddleAttempt) []string {
+	return r.Hints[:min(a.Hints, len(r.Hints))]
+}
+
+// RiddleAttempt returns the player's record on the riddle, which is zero
+// if they have never answered it.
+func (g *Game) RiddleAttempt(walletAddress, id string) RiddleAttempt {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return RiddleAttempt{}
+	}
+	return player.RiddleAttempts[id]
+}
+
+// Riddle looks up a riddle, refusing ones the player is done with.
+func (g *Game) Riddle(walletAddress, id string) (Riddle, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	riddle, _, err := g.openRiddle(walletAddress, id)
+	return riddle, err
+}
+
+// NextRiddle returns the easiest riddle on the topic that the player has
+// neither solved nor used up, so a riddle they got wrong comes back until
+// they run out of tries.
+func (g *Game) NextRiddle(walletAddress, topic string) (Riddle, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	riddles := g.riddleBank().Topic(topic)
+	if len(riddles) == 0 {
+		return Riddle{}, ErrUnknownRiddle
+	}
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return Riddle{}, ErrPlayerNotFound
+	}
+	for _, riddle := range riddles {
+		if !riddle.Locked(player.RiddleAttempts[riddle.ID]) {
+			return riddle, nil
+		}
+	}
+	return Riddle{}, ErrTopicExhausted
+}
+
+// openRiddle finds a riddle the player can still answer. The caller must
+// hold g.mu.
+func (g *Game) openRiddle(walletAddress, id string) (Riddle, *Player, error) {
+	riddle, ok := g.riddleBank().Riddle(id)
+	if !ok {
+		return Riddle{}, nil, ErrUnknownRiddle
+	}
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return Riddle{}, nil, ErrPlayerNotFound
+	}
+	if riddle.Locked(player.RiddleAttempts[id]) {
+		return Riddle{}, nil, ErrRiddleAttempted
+	}
+	return riddle, player, nil
+}
+
+// AnswerRiddle checks the player's answer and records it as a try. A
+// right answer pays the riddle's reward, less the penalty for the wrong
+// answers before it.
+func (g *Game) AnswerRiddle(walletAddress, id, answer string) (RiddleResult, error) {
+	g.mu.RLock()
+	riddle, err := g.answerableRiddle(walletAddress, id, time.Now())
+	g.mu.RUnlock()
+	if err != nil {
+		return RiddleResult{}, err
+	}
+	// Checking a code answer builds and runs it, which takes too long to
+	// hold the lock for.
+	correct, output, err := riddle.Answer.Check(answer)
+	if err != nil {
+		return RiddleResult{}, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now().UTC()
+	// Another answer may have been recorded in the meantime.
+	if riddle, err = g.answerableRiddle(walletAddress, id, now); err != nil {
+		return RiddleResult{}, err
+	}
+	attempt, paid, err := g.recordRiddle(walletAddress, riddle, correct, now)
+	if err != nil {
+		return RiddleResult{}, err
+	}
+	result := RiddleResult{
+		ID:           riddle.ID,
+		Language:     riddle.Language,
+		Correct:      correct,
+		Message:      riddle.Wrong,
+		Locked:       riddle.Locked(attempt),
+		Tries:        attempt.Tries,
+		AttemptsLeft: riddle.AttemptsLeft(attempt),
+		Paid:         paid,
+		Output:       output,
+	}
+	if correct {
+		result.Message = riddle.Correct
+	}
+	return result, nil
+}
+
+// answerableRiddle finds a riddle the player may answer now: one they can
+// still answer and aren't waiting out a cooldown on. The caller must hold
+// g.mu.
+func (g *Game) answerableRiddle(walletAddress, id string, now time.Time) (Riddle, error) {
+	riddle, player, err := g.openRiddle(walletAddress, id)
+	if err != nil {
+		return Riddle{}, err
+	}
+	if retryAt := riddle.RetryAt(player.RiddleAttempts[id]); now.Before(retryAt) {
+		return Riddle{}, fmt.Errorf("%w: wait %s", ErrRiddleCooldown, retryAt.Sub(now).Round(time.Second))
+	}
+	return riddle, nil
+}
+
+// RecordRiddle records a try at the riddle as if the player had answered
+// it now, paying out the reward when solved.
+func (g *Game) RecordRiddle(walletAddress, id string, solved bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	riddle, ok := g.riddleBank().Riddle(id)
+	if !ok {
+		return ErrUnknownRiddle
+	}
+	_, _, err := g.recordRiddle(walletAddress, riddle, solved, time.Now().UTC())
+	return err
+}
+
+// recordRiddle counts a try and, when solved, pays for it. Every earlier
+// try was wrong, since a solved riddle takes no more answers. The caller
+// must hold g.mu.
+func (g *Game) recordRiddle(walletAddress string, riddle Riddle, solved bool, now time.Time) (RiddleAttempt, Balances, error) {
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return RiddleAttempt{}, Balances{}, ErrPlayerNotFound
+	}
+	attempt := player.RiddleAttempts[riddle.ID]
+	wrong := attempt.Tries
+	attempt.Tries++
+	attempt.LastTry = now
+	attempt.Solved = solved
+	var paid Balances
+	if solved {
+		// Pay first, so a riddle is never locked as solved without the
+		// reward. Posted even when the penalty ate it all, so the solve
+		// still shows in the weekly riddle leaderboard.
+		paid = riddle.PayoutAfter(wrong)
+		err := g.post(LedgerEntry{
+			Actor:         SystemActor,
+			WalletAddress: walletAddress,
+			Balances:      paid,
+			Reason:        riddleRewardPrefix + riddle.ID + " riddle",
+		})
+		if err != nil {
+			return RiddleAttempt{}, Balances{}, err
+		}
+	}
+	err := g.updatePlayer(walletAddress, func(player *Player) {
+		if player.RiddleAttempts == nil {
+			player.RiddleAttempts = make(map[string]RiddleAttempt)
+		}
+		player.RiddleAttempts[riddle.ID] = attempt
+		if solved {
+			player.RiddleScore++
+		}
+	})
+	if err != nil {
+		return RiddleAttempt{}, Balances{}, err
+	}
+	return attempt, paid, g.fire(EventRiddle, walletAddress)
+}
+
+// RiddleHint reveals the player's next hint for the riddle and charges
+// them the riddle's hint cost for it. It returns every hint revealed so
+// far, the new one last.
+func (g *Game) RiddleHint(walletAddress, id string) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	riddle, player, err := g.openRiddle(walletAddress, id)
+	if err != nil {
+		return nil, err
+	}
+	revealed := len(riddle.RevealedHints(player.RiddleAttempts[id]))
+	if revealed >= len(riddle.Hints) {
+		return nil, ErrNoMoreHints
+	}
+	// Pay first, so a player who can't afford the hint doesn't get it.
+	if riddle.HintCost != (Balances{}) {
+		err := g.post(LedgerEntry{
+			Actor:         SystemActor,
+			WalletAddress: walletAddress,
+			Balances:      riddle.HintCost.percent(-100),
+			Reason:        fmt.Sprintf("hint %d for the %s riddle", revealed+1, id),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = g.updatePlayer(walletAddress, func(player *Player) {
+		if player.RiddleAttempts == nil {
+			player.RiddleAttempts = make(map[string]RiddleAttempt)
+		}
+		attempt := player.RiddleAttempts[id]
+		attempt.Hints = revealed + 1
+		player.RiddleAttempts[id] = attempt
+	})
+	if err != nil {
+		return nil, err
+	}
+	return riddle.Hints[:revealed+1], nil
+}
@@ -0,0 +1,174 @@
+// Description: This file contains the wallet sign-in flow (Sign-In with Ethereum style). A wallet asks for a one-time challenge message, signs it with its private key, and the signature is checked with secp256k1 ecrecover before a session is started.
+
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	loginDomain       = "ceptor.club"
+	challengeLifetime = 5 * time.Minute
+)
+
+// maxChallenges bounds the outstanding challenges, since anyone may ask
+// for one, for any address.
+var maxChallenges = 10000
+
+var (
+	ErrInvalidWallet     = errors.New("invalid wallet address")
+	ErrNoChallenge       = errors.New("no login challenge for this wallet, request a new nonce")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrSignatureMismatch = errors.New("signature does not match wallet address")
+)
+
+type challenge struct {
+	Message string
+	Expires time.Time
+}
+
+// ChallengeStore hands out one-time login messages, keyed by wallet address.
+type ChallengeStore struct {
+	mu         sync.Mutex
+	challenges map[string]challenge
+}
+
+// NewChallengeStore initializes an empty challenge store.
+func NewChallengeStore() *ChallengeStore {
+	return &ChallengeStore{challenges: make(map[string]challenge)}
+}
+
+// Issue creates a fresh challenge message for the wallet, replacing any
+// earlier one that was not used. Expired challenges are dropped on the way.
+func (c *ChallengeStore) Issue(walletAddress string) (string, error) {
+	if !isWalletAddress(walletAddress) {
+		return "", ErrInvalidWallet
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	message := fmt.Sprintf(`%s wants you to sign in with your Ethereum account:
+%s
+
+Sign in to Ceptor Club.
+
+Nonce: %s
+Issued At: %s`, loginDomain, walletAddress, hex.EncodeToString(nonce), now.Format(time.RFC3339))
+
+	c.mu.Lock()
+	c.sweep(now)
+	c.challenges[strings.ToLower(walletAddress)] = challenge{Message: message, Expires: now.Add(challengeLifetime)}
+	c.mu.Unlock()
+	return message, nil
+}
+
+// sweep drops the expired challenges and, if there are still too many to
+// add another, the oldest. The caller must hold c.mu.
+func (c *ChallengeStore) sweep(now time.Time) {
+	for key, ch := range c.challenges {
+		if now.After(ch.Expires) {
+			delete(c.challenges, key)
+		}
+	}
+	for len(c.challenges) >= maxChallenges {
+		var oldest string
+		for key, ch := range c.challenges {
+			if oldest == "" || ch.Expires.Before(c.challenges[oldest].Expires) {
+				oldest = key
+			}
+		}
+		delete(c.challenges, oldest)
+	}
+}
+
+// Consume returns the outstanding challenge for the wallet and removes it, so
+// every message can only be used once.
+func (c *ChallengeStore) Consume(walletAddress string) (string, error) {
+	key := strings.ToLower(walletAddress)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch, ok := c.challenges[key]
+	if !ok {
+		return "", ErrNoChallenge
+	}
+	delete(c.challenges, key)
+	if time.Now().After(ch.Expires) {
+		return "", ErrNoChallenge
+	}
+	return ch.Message, nil
+}
+
+// VerifyLogin consumes the wallet's challenge and checks that the signature
+// over it was made by the wallet's key.
+func (c *ChallengeStore) VerifyLogin(walletAddress, signature string) error {
+	message, err := c.Consume(walletAddress)
+	if err != nil {
+		return err
+	}
+	return VerifySignature(walletAddress, message, signature)
+}
+
+// VerifySignature checks a hex-encoded 65 byte personal_sign signature
+// (r || s || v) against the wallet address using ecrecover.
+func VerifySignature(walletAddress, message, signature string) error {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return ErrInvalidSignature
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return ErrInvalidSignature
+	}
+
+	// ecdsa.RecoverCompact wants the recovery code first: 27 + v for an uncompressed key.
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+
+	pubKey, _, err := ecdsa.RecoverCompact(compact, signedMessageHash(message))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	recovered := "0x" + hex.EncodeToString(keccak256(pubKey.SerializeUncompressed()[1:])[12:])
+	if !strings.EqualFold(recovered, walletAddress) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// signedMessageHash is the EIP-191 hash wallets sign for personal_sign.
+func signedMessageHash(message string) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return keccak256([]byte(prefix), []byte(message))
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// isWalletAddress reports whether s looks like a 0x-prefixed 20 byte hex address.
+func isWalletAddress(s string) bool {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
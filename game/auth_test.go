@@ -0,0 +1,105 @@
+package game
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+)
+
+// testWallet is a locally generated key pair standing in for a browser wallet.
+type testWallet struct {
+	key     *secp256k1.PrivateKey
+	address string
+}
+
+func newTestWallet(t *testing.T) testWallet {
+	t.Helper()
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "0x" + hex.EncodeToString(keccak256(key.PubKey().SerializeUncompressed()[1:])[12:])
+	return testWallet{key: key, address: address}
+}
+
+// sign produces a personal_sign style signature: r || s || v with v = 27 or 28.
+func (w testWallet) sign(message string) string {
+	compact := ecdsa.SignCompact(w.key, signedMessageHash(message), false)
+	sig := append(compact[1:], compact[0])
+	return "0x" + hex.EncodeToString(sig)
+}
+
+func TestVerifySignature(t *testing.T) {
+	wallet := newTestWallet(t)
+	other := newTestWallet(t)
+	message := "hello ceptor"
+
+	if err := VerifySignature(wallet.address, message, wallet.sign(message)); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := VerifySignature(strings.ToUpper(wallet.address[2:]), message, wallet.sign(message)); err != ErrSignatureMismatch {
+		t.Errorf("unprefixed address: got %v, want %v", err, ErrSignatureMismatch)
+	}
+	if err := VerifySignature(wallet.address, message, other.sign(message)); err != ErrSignatureMismatch {
+		t.Errorf("someone else's signature: got %v, want %v", err, ErrSignatureMismatch)
+	}
+	if err := VerifySignature(wallet.address, "other message", wallet.sign(message)); err != ErrSignatureMismatch {
+		t.Errorf("signature over a different message: got %v, want %v", err, ErrSignatureMismatch)
+	}
+	if err := VerifySignature(wallet.address, message, "0x1234"); err != ErrInvalidSignature {
+		t.Errorf("short signature: got %v, want %v", err, ErrInvalidSignature)
+	}
+}
+
+func TestChallengeIsSingleUse(t *testing.T) {
+	wallet := newTestWallet(t)
+	challenges := NewChallengeStore()
+
+	if _, err := challenges.Issue("0xTippi"); err != ErrInvalidWallet {
+		t.Errorf("Issue(0xTippi): got %v, want %v", err, ErrInvalidWallet)
+	}
+
+	message, err := challenges.Issue(wallet.address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := wallet.sign(message)
+	if err := challenges.VerifyLogin(wallet.address, signature); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if err := challenges.VerifyLogin(wallet.address, signature); err != ErrNoChallenge {
+		t.Errorf("replayed login: got %v, want %v", err, ErrNoChallenge)
+	}
+}
+
+func TestChallengesAreSwept(t *testing.T) {
+	challenges := NewChallengeStore()
+	challenges.challenges["0xstale"] = challenge{Message: "old", Expires: time.Now().Add(-time.Second)}
+	wallet := newTestWallet(t)
+	if _, err := challenges.Issue(wallet.address); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := challenges.challenges["0xstale"]; ok || len(challenges.challenges) != 1 {
+		t.Errorf("expired challenge was kept: %d outstanding", len(challenges.challenges))
+	}
+
+	defer func(n int) { maxChallenges = n }(maxChallenges)
+	maxChallenges = 3
+	for i := 0; i < 10; i++ {
+		if _, err := challenges.Issue(fmt.Sprintf("0x%040x", i)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond) // Keep the expiry times apart
+	}
+	if n := len(challenges.challenges); n != maxChallenges {
+		t.Errorf("%d challenges outstanding, want %d", n, maxChallenges)
+	}
+	if _, ok := challenges.challenges[fmt.Sprintf("0x%040x", 9)]; !ok {
+		t.Error("the newest challenge was dropped")
+	}
+}
@@ -0,0 +1,471 @@
+// Package game holds the club's core state: players, their tokens and XP,
+// the allow list and Purgatory, plus roles, sessions, wallet login and
+// riddles. It has no knowledge of the REPL or the HTTP server.
+package game
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+type Player struct {
+	WalletAddress  string
+	PlayerName     string
+	Balances                                // Derived from the ledger; see Replay
+	RiddleAttempts map[string]RiddleAttempt // By riddle ID
+	RiddleScore    int                      // Track riddle score
+	Role           Role                     // Empty in saves made before roles existed, treated as RolePlayer
+	Visited        map[string]bool          // Locations read, by name
+	Badges         map[string]time.Time     // Achievement ID to when it was unlocked
+	Notifications  []string                 `json:",omitempty"` // Not yet shown to the player
+	Removal        *Removal                 `json:",omitempty"` // Set while the player is in Purgatory
+}
+
+// Game is safe for concurrent use: the HTTP server and the REPL share one
+// *Game, so everything that touches the maps goes through methods that hold mu.
+type Game struct {
+	mu        sync.RWMutex
+	store     Store              // Optional; nil keeps the game in memory only
+	Players   map[string]*Player // Keyed by wallet address
+	AllowList map[string]bool    // Keyed by wallet address
+	Purgatory map[string]*Player // Keyed by wallet address
+	Ledger    []LedgerEntry      // Oldest first, append only
+	Offers    map[int]*Offer     // Marketplace, keyed by offer ID
+	levels    *LevelConfig       // Nil means DefaultLevelConfig
+	riddles   *RiddleBank        // Nil means DefaultRiddleBank
+	sessions  *SessionStore      // Optional; removed players are logged out of it
+}
+
+const tippiWalletAddress = "0xTippi"
+
+var (
+	ErrPlayerExists   = errors.New("player already exists")
+	ErrPlayerNotFound = errors.New("player not found")
+	ErrSaveOverStore  = errors.New("that file is where the game is stored; save it somewhere else")
+)
+
+// adminWalletEnv names a real wallet to seed as admin, since the placeholder
+// tippiWalletAddress cannot sign a login challenge.
+const adminWalletEnv = "CEPTOR_ADMIN_WALLET"
+
+// New initializes a new game environment that lives in memory only.
+func New() *Game {
+	game := &Game{
+		Players:   make(map[string]*Player),
+		AllowList: make(map[string]bool),
+		Purgatory: make(map[string]*Player),
+		Offers:    make(map[int]*Offer),
+	}
+	game.seed()
+	game.grantEnvAdmin() // Nothing to fail without a store
+	return game
+}
+
+// Open starts a game backed by the store. An empty store is seeded with the
+// same starting players as New, and the admin wallet from the environment is
+// made an admin every time.
+func Open(store Store) (*Game, error) {
+	snapshot, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.fillDefaults()
+	game := &Game{store: store, Players: snapshot.Players, AllowList: snapshot.AllowList, Purgatory: snapshot.Purgatory, Ledger: snapshot.Ledger, Offers: snapshot.Offers}
+	if len(game.Players) == 0 && len(game.Purgatory) == 0 {
+		game.seed()
+		if err := store.Replace(game.snapshot()); err != nil {
+			return nil, err
+		}
+	}
+	// Players written before a crash may lag behind the ledger.
+	if err := game.rebuild(true); err != nil {
+		return nil, err
+	}
+	if err := game.grantEnvAdmin(); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// Close closes the game's store, if it has one.
+func (g *Game) Close() error {
+	if g.store == nil {
+		return nil
+	}
+	return g.store.Close()
+}
+
+// seed adds Tippi.
+func (g *Game) seed() {
+	// Add "0xTippi" to the AllowList
+	g.AllowList["0xTippi"] = true
+
+	tippi := Balances{GameTokens: 10, ArtTokens: 5, TechTokens: 20, ArtXP: 100, GameXP: 500, TechXP: 1000}
+	g.Players["0xTippi"] = &Player{
+		WalletAddress:  "0xTippi",
+		PlayerName:     "Tippi",
+		Balances:       tippi,
+		RiddleAttempts: make(map[string]RiddleAttempt),
+		Visited:        make(map[string]bool),
+		Badges:         make(map[string]time.Time),
+		RiddleScore:    5,
+		Role:           RoleAdmin,
+	}
+	g.Ledger = append(g.Ledger, startingBalance("0xTippi", tippi, time.Now().UTC()))
+}
+
+// grantEnvAdmin makes the wallet from the environment, if set, an admin on
+// the allow list, adding it as a player if need be. Its role is restored on
+// every start, so an existing game can still be run from a real wallet. A
+// wallet in Purgatory stays there. The caller must own the game exclusively.
+func (g *Game) grantEnvAdmin() error {
+	adminWallet := os.Getenv(adminWalletEnv)
+	if adminWallet == "" {
+		return nil
+	}
+	if _, removed := g.Purgatory[adminWallet]; removed {
+		return nil
+	}
+	var admin *Player
+	if player, exists := g.Players[adminWallet]; exists {
+		if player.Role == RoleAdmin && g.AllowList[adminWallet] {
+			return nil
+		}
+		admin = player.Clone()
+		admin.Role = RoleAdmin
+	} else {
+		admin = &Player{
+			WalletAddress:  adminWallet,
+			PlayerName:     "Admin",
+			RiddleAttempts: make(map[string]RiddleAttempt),
+			Visited:        make(map[string]bool),
+			Badges:         make(map[string]time.Time),
+			Role:           RoleAdmin,
+		}
+	}
+	if g.store != nil {
+		if err := g.store.PutPlayer(admin.Clone()); err != nil {
+			return err
+		}
+		if err := g.store.SetAllowed(adminWallet, true); err != nil {
+			return err
+		}
+	}
+	g.Players[adminWallet] = admin
+	g.AllowList[adminWallet] = true
+	return nil
+}
+
+// newPlayerBalances are what AddPlayer starts everyone on.
+var newPlayerBalances = Balances{GameTokens: 5, ArtTokens: 1, TechTokens: 10}
+
+func startingBalance(walletAddress string, balances Balances, at time.Time) LedgerEntry {
+	return LedgerEntry{Actor: SystemActor, WalletAddress: walletAddress, Balances: balances, Reason: "starting balance", Time: at}
+}
+
+// snapshot returns the game's state for a store. The caller must hold g.mu
+// (or own the game exclusively).
+func (g *Game) snapshot() *Snapshot {
+	return &Snapshot{Players: g.Players, AllowList: g.AllowList, Purgatory: g.Purgatory, Ledger: g.Ledger, Offers: g.Offers}
+}
+
+// Login checks whether the wallet address may log in. The caller is
+// responsible for starting a session when it returns true.
+func (g *Game) Login(walletAddress string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.isAllowed(walletAddress) {
+		return false
+	}
+	// A badge that fails to save is simply awarded on a later login.
+	g.fire(EventLogin, walletAddress)
+	return true
+}
+
+// Save writes the current game state to a file in the current save format.
+// It refuses to write over the file the game's store is using.
+func (g *Game) Save(filename string) error {
+	if g.storesIn(filename) {
+		return ErrSaveOverStore
+	}
+	g.mu.RLock()
+	data, err := EncodeSave(g.snapshot(), time.Now())
+	g.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// storesIn reports whether filename is the file the game's store is kept in.
+func (g *Game) storesIn(filename string) bool {
+	store, ok := g.store.(pathStore)
+	if !ok {
+		return false
+	}
+	target, err := os.Stat(filename)
+	if err != nil {
+		return false // Nothing there yet, so it can't be the store
+	}
+	stored, err := os.Stat(store.Path())
+	return err == nil && os.SameFile(target, stored)
+}
+
+// Load reads a game state from a file, upgrading older save formats.
+func Load(filename string) (*Game, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := DecodeSave(data)
+	if err != nil {
+		return nil, err
+	}
+	game := &Game{Players: snapshot.Players, AllowList: snapshot.AllowList, Purgatory: snapshot.Purgatory, Ledger: snapshot.Ledger, Offers: snapshot.Offers}
+	if err := game.rebuild(false); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
+// Replace swaps in the state of another game, typically one just read by
+// Load. The store, if any, is overwritten with it too.
+func (g *Game) Replace(other *Game) error {
+	other.mu.RLock()
+	snapshot := other.snapshot()
+	other.mu.RUnlock()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.store != nil {
+		if err := g.store.Replace(snapshot); err != nil {
+			return err
+		}
+	}
+	g.Players, g.AllowList, g.Purgatory, g.Ledger, g.Offers = snapshot.Players, snapshot.AllowList, snapshot.Purgatory, snapshot.Ledger, snapshot.Offers
+	if g.Players == nil {
+		g.Players = make(map[string]*Player)
+	}
+	if g.AllowList == nil {
+		g.AllowList = make(map[string]bool)
+	}
+	if g.Purgatory == nil {
+		g.Purgatory = make(map[string]*Player)
+	}
+	if g.Offers == nil {
+		g.Offers = make(map[int]*Offer)
+	}
+	return nil
+}
+
+// Fork returns an in-memory copy of the game that shares nothing with it,
+// so commands can be tried out on the copy without touching the original
+// or its store.
+func (g *Game) Fork() (*Game, error) {
+	g.mu.RLock()
+	data, err := EncodeSave(g.snapshot(), time.Now())
+	levels, riddles := g.levels, g.riddles
+	g.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := DecodeSave(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Game{Players: snapshot.Players, AllowList: snapshot.AllowList, Purgatory: snapshot.Purgatory, Ledger: snapshot.Ledger, Offers: snapshot.Offers, levels: levels, riddles: riddles}, nil
+}
+
+func (g *Game) IsAllowed(walletAddress string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.isAllowed(walletAddress)
+}
+
+func (g *Game) isAllowed(walletAddress string) bool {
+	_, allowed := g.AllowList[walletAddress]
+	return allowed
+}
+
+// Player returns a copy of the player, safe to read while the game keeps changing.
+func (g *Game) Player(walletAddress string) (Player, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	player, exists := g.Players[walletAddress]
+	if !exists || player == nil {
+		return Player{}, false
+	}
+	return player.clone(), true
+}
+
+// ListPlayers returns copies of all active players.
+func (g *Game) ListPlayers() []Player {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	players := make([]Player, 0, len(g.Players))
+	for _, player := range g.Players {
+		players = append(players, player.clone())
+	}
+	return players
+}
+
+// AllowedWallets returns the wallet addresses on the allow list.
+func (g *Game) AllowedWallets() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	wallets := make([]string, 0, len(g.AllowList))
+	for walletAddress := range g.AllowList {
+		wallets = append(wallets, walletAddress)
+	}
+	return wallets
+}
+
+// clone copies the player, including its RiddleAttempts map.
+func (p *Player) clone() Player {
+	c := *p
+	c.RiddleAttempts = make(map[string]RiddleAttempt, len(p.RiddleAttempts))
+	for id, attempt := range p.RiddleAttempts {
+		c.RiddleAttempts[id] = attempt
+	}
+	c.Visited = make(map[string]bool, len(p.Visited))
+	for name, visited := range p.Visited {
+		c.Visited[name] = visited
+	}
+	c.Badges = make(map[string]time.Time, len(p.Badges))
+	for id, unlocked := range p.Badges {
+		c.Badges[id] = unlocked
+	}
+	c.Notifications = append([]string(nil), p.Notifications...)
+	if p.Removal != nil {
+		removal := *p.Removal
+		c.Removal = &removal
+	}
+	return c
+}
+
+// When adding a new player, initialize the RiddleAttempts map
+func (g *Game) AddPlayer(walletAddress, playerName string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, removed := g.Purgatory[walletAddress]; removed {
+		return ErrInPurgatory // Adding them afresh would pay the starting balance twice
+	}
+	if _, exists := g.AllowList[walletAddress]; !exists {
+		// A returning wallet keeps whatever its earlier ledger entries add up to.
+		balances := Replay(g.Ledger)[walletAddress].Add(newPlayerBalances)
+		player := &Player{
+			WalletAddress:  walletAddress,
+			PlayerName:     playerName,
+			Balances:       balances,
+			RiddleAttempts: make(map[string]RiddleAttempt), // Initialize the map
+			Visited:        make(map[string]bool),
+			Badges:         make(map[string]time.Time),
+		}
+		// Write the player before the ledger entry, so a failed write never
+		// leaves a starting balance behind for a re-add to pay again.
+		if g.store != nil {
+			if err := g.store.PutPlayer(player.Clone()); err != nil {
+				return err
+			}
+			if err := g.store.SetAllowed(walletAddress, true); err != nil {
+				g.store.DeletePlayer(walletAddress)
+				return err
+			}
+		}
+		if err := g.appendLedger(startingBalance(walletAddress, newPlayerBalances, time.Time{})); err != nil {
+			if g.store != nil {
+				g.store.SetAllowed(walletAddress, false)
+				g.store.DeletePlayer(walletAddress)
+			}
+			return err
+		}
+		g.Players[walletAddress] = player
+		g.AllowList[walletAddress] = true
+		return nil
+	}
+	return ErrPlayerExists
+}
+
+// RemovePlayer removes a player from the game, moving them to Purgatory
+// with a note of who removed them, when and why. Their open offers are
+// cancelled and the escrow refunded, and they are logged out. Only an admin
+// may remove an admin, and never the last one.
+func (g *Game) RemovePlayer(actor, walletAddress, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.Players[walletAddress]; exists {
+		// The same rules as SetRole, so removal can't get round them.
+		if g.roleOf(walletAddress) == RoleAdmin {
+			if g.roleOf(actor) != RoleAdmin {
+				return ErrPermissionDenied // Only an admin may remove an admin
+			}
+			if g.countRole(RoleAdmin) == 1 {
+				return ErrLastAdmin
+			}
+		}
+		// Nobody could accept or cancel their offers once they are gone.
+		if err := g.cancelOffersBy(actor, walletAddress); err != nil {
+			return err
+		}
+		removed := g.Players[walletAddress].Clone()
+		removed.Removal = &Removal{RemovedBy: actor, RemovedAt: time.Now().UTC(), Reason: reason}
+		if g.store != nil {
+			if err := g.store.PutPurgatory(removed.Clone()); err != nil {
+				return err
+			}
+			if err := g.store.DeletePlayer(walletAddress); err != nil {
+				return err
+			}
+			if err := g.store.SetAllowed(walletAddress, false); err != nil {
+				return err
+			}
+		}
+		g.Purgatory[walletAddress] = removed // Move to Purgatory
+		delete(g.Players, walletAddress)     // Remove from active players
+		delete(g.AllowList, walletAddress)   // Remove from allow list
+		if g.sessions != nil {
+			g.sessions.DeleteWallet(walletAddress)
+		}
+		return nil
+	}
+	return ErrPlayerNotFound
+}
+
+// SetSessionStore names the sessions to end when a player is removed.
+func (g *Game) SetSessionStore(sessions *SessionStore) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sessions = sessions
+}
+
+// updatePlayer applies change to a copy of the player, writes the copy to
+// the store and only then swaps it in. The caller must hold g.mu.
+func (g *Game) updatePlayer(walletAddress string, change func(player *Player)) error {
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return ErrPlayerNotFound
+	}
+	updated := player.Clone()
+	change(updated)
+	if g.store != nil {
+		if err := g.store.PutPlayer(updated.Clone()); err != nil {
+			return err
+		}
+	}
+	g.Players[walletAddress] = updated
+	return nil
+}
+
+// HasAttemptedRiddle reports whether the player has answered the riddle at least once.
+func (g *Game) HasAttemptedRiddle(walletAddress, id string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return false
+	}
+	return player.RiddleAttempts[id].Tries > 0
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestGameConcurrentAccess hammers the mutating Game methods from many
+// goroutines at once, the way the HTTP server and the REPL do. Run it with
+// "go test -race" to catch unguarded map access.
+func TestGameConcurrentAccess(t *testing.T) {
+	game := New()
+	const workers = 16
+	const rounds = 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				wallet := fmt.Sprintf("0xPlayer%d_%d", w, i)
+				game.AddPlayer(wallet, "Player")
+				game.Login(wallet)
+				game.AwardTokensXP(tippiWalletAddress, wallet, Balances{1, 1, 1, 1, 1, 1}, "")
+				game.AwardTokensXP(tippiWalletAddress, tippiWalletAddress, Balances{GameTokens: 1}, "")
+				game.RecordRiddle(wallet, "go", true)
+				game.ListPlayers()
+				game.AllowedWallets()
+				game.Can(&Session{WalletAddress: wallet}, PermAward)
+				if i%2 == 0 {
+					game.RemovePlayer(SystemActor, wallet, "")
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	tippi, ok := game.Player(tippiWalletAddress)
+	if !ok {
+		t.Fatal("Tippi went missing")
+	}
+	if want := 10 + workers*rounds; tippi.GameTokens != want {
+		t.Errorf("Tippi GameTokens = %d, want %d", tippi.GameTokens, want)
+	}
+	if got, want := len(game.ListPlayers()), 1+workers*rounds/2; got != want {
+		t.Errorf("active players = %d, want %d", got, want)
+	}
+	if got, want := len(game.Purgatory), workers*rounds/2; got != want {
+		t.Errorf("players in purgatory = %d, want %d", got, want)
+	}
+}
+
+func TestPlayerCopyIsDetached(t *testing.T) {
+	game := New()
+	game.AddPlayer("0xDisco", "Discordian")
+
+	snapshot, _ := game.Player("0xDisco")
+	game.RecordRiddle("0xDisco", "go", true)
+
+	if snapshot.RiddleScore != 0 || len(snapshot.RiddleAttempts) != 0 {
+		t.Errorf("snapshot changed after the game did: %+v", snapshot)
+	}
+	if !game.HasAttemptedRiddle("0xDisco", "go") {
+		t.Error("riddle attempt was not recorded")
+	}
+}
+
+// TestAddPlayerStoreFailure checks that a player who couldn't be stored
+// leaves no starting balance behind to be paid twice.
+func TestAddPlayerStoreFailure(t *testing.T) {
+	store := &failingStore{fail: map[string]bool{}}
+	g, err := Open(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ledger := len(g.Ledger)
+	for _, method := range []string{"PutPlayer", "SetAllowed", "AppendLedger"} {
+		store.fail = map[string]bool{method: true}
+		if err := g.AddPlayer("0xNew", "Newcomer"); !errors.Is(err, errStoreDown) {
+			t.Errorf("%s failing: err = %v", method, err)
+		}
+		if _, ok := g.Player("0xNew"); ok || g.IsAllowed("0xNew") || len(g.Ledger) != ledger {
+			t.Errorf("%s failing: the player was half added", method)
+		}
+	}
+
+	store.fail = nil
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	if player, _ := g.Player("0xNew"); player.Balances != newPlayerBalances || len(g.Ledger) != ledger+1 {
+		t.Errorf("balances = %+v after %d ledger entries, want %+v after 1", player.Balances, len(g.Ledger)-ledger, newPlayerBalances)
+	}
+}
+
+var errStoreDown = errors.New("store is down")
+
+// failingStore keeps nothing, and fails the writes whose method names are
+// set in fail.
+type failingStore struct {
+	fail map[string]bool
+}
+
+func (s *failingStore) err(method string) error {
+	if s.fail[method] {
+		return errStoreDown
+	}
+	return nil
+}
+
+func (s *failingStore) Load() (*Snapshot, error)              { return NewSnapshot(), nil }
+func (s *failingStore) GetPlayer(string) (*Player, error)     { return nil, ErrPlayerNotFound }
+func (s *failingStore) PutPlayer(*Player) error               { return s.err("PutPlayer") }
+func (s *failingStore) DeletePlayer(string) error             { return s.err("DeletePlayer") }
+func (s *failingStore) SetAllowed(string, bool) error         { return s.err("SetAllowed") }
+func (s *failingStore) PutPurgatory(*Player) error            { return s.err("PutPurgatory") }
+func (s *failingStore) DeletePurgatory(string) error          { return s.err("DeletePurgatory") }
+func (s *failingStore) AppendLedger(...LedgerEntry) error     { return s.err("AppendLedger") }
+func (s *failingStore) PutOffer(*Offer, ...LedgerEntry) error { return s.err("PutOffer") }
+func (s *failingStore) Replace(*Snapshot) error               { return s.err("Replace") }
+func (s *failingStore) Close() error                          { return nil }
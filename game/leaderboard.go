@@ -0,0 +1,153 @@
+// Description: This file contains the leaderboards. Players are ranked by one metric, either on their totals (all time) or on what the ledger recorded for them in the last seven days (weekly).
+
+package game
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Metric is what a leaderboard ranks players by.
+type Metric string
+
+const (
+	MetricGameXP  Metric = "gamexp"
+	MetricArtXP   Metric = "artxp"
+	MetricTechXP  Metric = "techxp"
+	MetricTokens  Metric = "tokens"  // Game, Art and Tech tokens added together
+	MetricRiddles Metric = "riddles" // RiddleScore
+)
+
+// Metrics lists the leaderboard metrics in display order.
+var Metrics = []Metric{MetricGameXP, MetricArtXP, MetricTechXP, MetricTokens, MetricRiddles}
+
+// Period is the window a leaderboard covers.
+type Period string
+
+const (
+	PeriodAllTime Period = "alltime"
+	PeriodWeekly  Period = "weekly"
+)
+
+// LeaderboardRow is one player's place on a leaderboard. Players with the
+// same score share a rank.
+type LeaderboardRow struct {
+	Rank          int
+	WalletAddress string
+	PlayerName    string
+	Score         int
+}
+
+var (
+	ErrUnknownMetric = errors.New("unknown leaderboard metric (options: gamexp, artxp, techxp, tokens, riddles)")
+	ErrUnknownPeriod = errors.New("unknown leaderboard period (options: alltime, weekly)")
+)
+
+// riddleRewardPrefix starts the reason of every riddle reward, which is how
+// the weekly riddles leaderboard finds solved riddles in the ledger.
+const riddleRewardPrefix = "solved the "
+
+// ParseMetric turns user input such as "GameXP" into a Metric.
+func ParseMetric(s string) (Metric, error) {
+	metric := Metric(strings.ToLower(s))
+	for _, known := range Metrics {
+		if metric == known {
+			return metric, nil
+		}
+	}
+	return "", ErrUnknownMetric
+}
+
+// ParsePeriod turns user input into a Period. An empty string means all time.
+func ParsePeriod(s string) (Period, error) {
+	switch period := Period(strings.ToLower(s)); period {
+	case "", PeriodAllTime:
+		return PeriodAllTime, nil
+	case PeriodWeekly:
+		return period, nil
+	}
+	return "", ErrUnknownPeriod
+}
+
+// Leaderboard ranks every player by the metric over the period, highest
+// score first. Ties are listed by player name.
+func (g *Game) Leaderboard(metric Metric, period Period) []LeaderboardRow {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.leaderboard(metric, period, time.Now().UTC())
+}
+
+// leaderboard is Leaderboard as of now. The caller must hold g.mu.
+func (g *Game) leaderboard(metric Metric, period Period, now time.Time) []LeaderboardRow {
+	var weekly map[string]int
+	if period == PeriodWeekly {
+		weekly = make(map[string]int)
+		since := now.AddDate(0, 0, -7)
+		for _, entry := range g.Ledger {
+			if entry.Time.Before(since) {
+				continue
+			}
+			weekly[entry.WalletAddress] += metric.score(entry)
+		}
+	}
+
+	rows := make([]LeaderboardRow, 0, len(g.Players))
+	for _, player := range g.Players {
+		row := LeaderboardRow{WalletAddress: player.WalletAddress, PlayerName: player.PlayerName}
+		switch {
+		case weekly != nil:
+			row.Score = weekly[player.WalletAddress]
+		case metric == MetricRiddles:
+			row.Score = player.RiddleScore
+		default:
+			row.Score = metric.balance(player.Balances)
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Score != rows[j].Score {
+			return rows[i].Score > rows[j].Score
+		}
+		if rows[i].PlayerName != rows[j].PlayerName {
+			return rows[i].PlayerName < rows[j].PlayerName
+		}
+		return rows[i].WalletAddress < rows[j].WalletAddress
+	})
+	for i := range rows {
+		if i > 0 && rows[i].Score == rows[i-1].Score {
+			rows[i].Rank = rows[i-1].Rank
+		} else {
+			rows[i].Rank = i + 1
+		}
+	}
+	return rows
+}
+
+// balance picks the metric out of a player's balances.
+func (m Metric) balance(b Balances) int {
+	switch m {
+	case MetricGameXP:
+		return b.GameXP
+	case MetricArtXP:
+		return b.ArtXP
+	case MetricTechXP:
+		return b.TechXP
+	case MetricTokens:
+		return b.GameTokens + b.ArtTokens + b.TechTokens
+	default:
+		return 0
+	}
+}
+
+// score is how much a ledger entry adds to the metric.
+func (m Metric) score(entry LedgerEntry) int {
+	if m == MetricRiddles {
+		if entry.Actor == SystemActor && strings.HasPrefix(entry.Reason, riddleRewardPrefix) {
+			return 1
+		}
+		return 0
+	}
+	return m.balance(entry.Balances)
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeaderboard(t *testing.T) {
+	g := New()
+	for _, walletAddress := range []string{"0xAda", "0xBob"} {
+		if err := g.AddPlayer(walletAddress, walletAddress[2:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Tippi's starting balance is a month old, so it only counts all time.
+	g.Ledger[0].Time = time.Now().UTC().AddDate(0, -1, 0)
+	if err := g.AwardTokensXP(SystemActor, "0xAda", Balances{GameXP: 500}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RecordRiddle("0xBob", "go", true); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		metric Metric
+		period Period
+		want   []LeaderboardRow
+	}{
+		{MetricGameXP, PeriodAllTime, []LeaderboardRow{
+			{1, "0xAda", "Ada", 500}, {1, "0xTippi", "Tippi", 500}, {3, "0xBob", "Bob", 5},
+		}},
+		{MetricGameXP, PeriodWeekly, []LeaderboardRow{
+			{1, "0xAda", "Ada", 500}, {2, "0xBob", "Bob", 5}, {3, "0xTippi", "Tippi", 0},
+		}},
+		{MetricTokens, PeriodAllTime, []LeaderboardRow{
+			// Ada's 500 Game XP paid out 9 tokens of level-up rewards.
+			{1, "0xTippi", "Tippi", 35}, {2, "0xAda", "Ada", 25}, {3, "0xBob", "Bob", 16},
+		}},
+		{MetricRiddles, PeriodAllTime, []LeaderboardRow{
+			{1, "0xTippi", "Tippi", 5}, {2, "0xBob", "Bob", 1}, {3, "0xAda", "Ada", 0},
+		}},
+		{MetricRiddles, PeriodWeekly, []LeaderboardRow{
+			{1, "0xBob", "Bob", 1}, {2, "0xAda", "Ada", 0}, {2, "0xTippi", "Tippi", 0},
+		}},
+	} {
+		got := g.Leaderboard(tc.metric, tc.period)
+		if len(got) != len(tc.want) {
+			t.Errorf("%s %s: got %d rows, want %d", tc.metric, tc.period, len(got), len(tc.want))
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s %s row %d = %+v, want %+v", tc.metric, tc.period, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestParseLeaderboardOptions(t *testing.T) {
+	if metric, err := ParseMetric("GameXP"); err != nil || metric != MetricGameXP {
+		t.Errorf("ParseMetric(GameXP) = %q, %v", metric, err)
+	}
+	if _, err := ParseMetric("karma"); !errors.Is(err, ErrUnknownMetric) {
+		t.Errorf("ParseMetric(karma): err = %v", err)
+	}
+	if period, err := ParsePeriod(""); err != nil || period != PeriodAllTime {
+		t.Errorf("ParsePeriod(\"\") = %q, %v", period, err)
+	}
+	if _, err := ParsePeriod("monthly"); !errors.Is(err, ErrUnknownPeriod) {
+		t.Errorf("ParsePeriod(monthly): err = %v", err)
+	}
+}
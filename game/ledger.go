@@ -0,0 +1,219 @@
+// Description: This file contains the append-only ledger of token and XP changes. Player balances are derived from it: every change is recorded first and applied second, so replaying the ledger always gives the same balances.
+
+package game
+
+import (
+	"errors"
+	"time"
+)
+
+// Balances are a player's tokens and XP. In a LedgerEntry they are the
+// change rather than the total.
+type Balances struct {
+	GameTokens int
+	ArtTokens  int
+	TechTokens int
+	ArtXP      int
+	GameXP     int
+	TechXP     int
+}
+
+// Add returns b with delta applied.
+func (b Balances) Add(delta Balances) Balances {
+	return Balances{
+		GameTokens: b.GameTokens + delta.GameTokens,
+		ArtTokens:  b.ArtTokens + delta.ArtTokens,
+		TechTokens: b.TechTokens + delta.TechTokens,
+		ArtXP:      b.ArtXP + delta.ArtXP,
+		GameXP:     b.GameXP + delta.GameXP,
+		TechXP:     b.TechXP + delta.TechXP,
+	}
+}
+
+// hasNegative reports whether any of the six values is below zero.
+func (b Balances) hasNegative() bool {
+	return b.GameTokens < 0 || b.ArtTokens < 0 || b.TechTokens < 0 || b.ArtXP < 0 || b.GameXP < 0 || b.TechXP < 0
+}
+
+// LedgerEntry is one change to a player's balances. Entries are only ever
+// appended, never edited or removed; a mistake is fixed by a new entry.
+type LedgerEntry struct {
+	Actor         string // Wallet that made the change, or SystemActor
+	WalletAddress string // Player whose balances change
+	Balances             // The change
+	Reason        string
+	Time          time.Time // Zero for opening balances carried over from saves made before the ledger
+}
+
+// SystemActor is the actor of changes the game makes by itself, such as
+// starting balances and riddle rewards.
+const SystemActor = "system"
+
+var (
+	ErrReasonRequired      = errors.New("taking tokens or XP away needs a reason")
+	ErrInsufficientBalance = errors.New("not enough tokens or XP")
+)
+
+// Replay sums the entries into balances per wallet address.
+func Replay(entries []LedgerEntry) map[string]Balances {
+	balances := make(map[string]Balances)
+	for _, entry := range entries {
+		balances[entry.WalletAddress] = balances[entry.WalletAddress].Add(entry.Balances)
+	}
+	return balances
+}
+
+// AwardTokensXP records a change to a player's tokens and XP on behalf of
+// actor. Negative values take tokens or XP away; they need a reason and may
+// not leave the player below zero.
+func (g *Game) AwardTokensXP(actor, walletAddress string, delta Balances, reason string) error {
+	if delta.hasNegative() && reason == "" {
+		return ErrReasonRequired
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.post(LedgerEntry{Actor: actor, WalletAddress: walletAddress, Balances: delta, Reason: reason})
+}
+
+// History returns the ledger entries for the wallet, oldest first.
+func (g *Game) History(walletAddress string) []LedgerEntry {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var entries []LedgerEntry
+	for _, entry := range g.Ledger {
+		if entry.WalletAddress == walletAddress {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Rebuild replays the ledger and overwrites every player's balances with
+// the result, writing any player that changed back to the store.
+func (g *Game) Rebuild() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rebuild(true)
+}
+
+// rebuild is Rebuild for callers that hold g.mu. With writeThrough unset
+// only the in-memory players are touched.
+func (g *Game) rebuild(writeThrough bool) error {
+	balances := Replay(g.Ledger)
+	for _, players := range []map[string]*Player{g.Players, g.Purgatory} {
+		for walletAddress, player := range players {
+			if player.Balances == balances[walletAddress] {
+				continue
+			}
+			updated := player.Clone()
+			updated.Balances = balances[walletAddress]
+			if writeThrough && g.store != nil {
+				var err error
+				if _, active := g.Players[walletAddress]; active {
+					err = g.store.PutPlayer(updated.Clone())
+				} else {
+					err = g.store.PutPurgatory(updated.Clone())
+				}
+				if err != nil {
+					return err
+				}
+			}
+			players[walletAddress] = updated
+		}
+	}
+	return nil
+}
+
+// post appends the entries, plus any level-up rewards they trigger, to the
+// ledger as one atomic batch and applies them to the players. Nothing is
+// written unless every player exists and stays at or above zero. The caller
+// must hold g.mu.
+func (g *Game) post(entries ...LedgerEntry) error {
+	after, err := g.balancesAfter(entries)
+	if err != nil {
+		return err
+	}
+	// Level-ups pay out in the same batch as the XP that earned them.
+	var rewards []LedgerEntry
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if walletAddress := entry.WalletAddress; !seen[walletAddress] {
+			seen[walletAddress] = true
+			rewards = append(rewards, g.levelConfig().levelUpRewards(walletAddress, g.Players[walletAddress].Balances, after[walletAddress])...)
+		}
+	}
+	if len(rewards) > 0 {
+		entries = append(entries, rewards...)
+		if after, err = g.balancesAfter(entries); err != nil {
+			return err
+		}
+	}
+	if err := g.appendLedger(entries...); err != nil {
+		return err
+	}
+	if err := g.applyBalances(after); err != nil {
+		return err
+	}
+	for walletAddress := range seen {
+		if err := g.fire(EventBalance, walletAddress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// balancesAfter works out what the entries would leave each affected player
+// with, refusing unknown players and overdrafts. The caller must hold g.mu.
+func (g *Game) balancesAfter(entries []LedgerEntry) (map[string]Balances, error) {
+	after := make(map[string]Balances)
+	for _, entry := range entries {
+		player, exists := g.Players[entry.WalletAddress]
+		if !exists {
+			return nil, ErrPlayerNotFound
+		}
+		balances, seen := after[entry.WalletAddress]
+		if !seen {
+			balances = player.Balances
+		}
+		after[entry.WalletAddress] = balances.Add(entry.Balances)
+		if after[entry.WalletAddress].hasNegative() {
+			return nil, ErrInsufficientBalance
+		}
+	}
+	return after, nil
+}
+
+// applyBalances writes balances from balancesAfter to the players, once
+// their ledger entries are safely stored. The caller must hold g.mu.
+func (g *Game) applyBalances(after map[string]Balances) error {
+	for walletAddress, balances := range after {
+		err := g.updatePlayer(walletAddress, func(player *Player) { player.Balances = balances })
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendLedger stamps the entries and writes them to the store, all or
+// nothing, and then to the in-memory ledger. The caller must hold g.mu.
+func (g *Game) appendLedger(entries ...LedgerEntry) error {
+	stamp(entries)
+	if g.store != nil {
+		if err := g.store.AppendLedger(entries...); err != nil {
+			return err
+		}
+	}
+	g.Ledger = append(g.Ledger, entries...)
+	return nil
+}
+
+// stamp dates entries that don't have a time yet.
+func stamp(entries []LedgerEntry) {
+	now := time.Now().UTC()
+	for i := range entries {
+		if entries[i].Time.IsZero() {
+			entries[i].Time = now
+		}
+	}
+}
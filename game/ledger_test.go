@@ -0,0 +1,77 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLedgerDrivesBalances(t *testing.T) {
+	g := New()
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{ArtTokens: 3, ArtXP: 10}, "drew the map"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{GameTokens: -2}, ""); !errors.Is(err, ErrReasonRequired) {
+		t.Errorf("negative award without reason: err = %v, want ErrReasonRequired", err)
+	}
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{GameTokens: -6}, "too much"); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("overdraft: err = %v, want ErrInsufficientBalance", err)
+	}
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{GameTokens: -2}, "bought snacks"); err != nil {
+		t.Fatal(err)
+	}
+
+	history := g.History("0xNew")
+	if len(history) != 3 {
+		t.Fatalf("history has %d entries, want 3: %+v", len(history), history)
+	}
+	if history[0].Actor != SystemActor || history[1].Actor != tippiWalletAddress || history[2].Reason != "bought snacks" {
+		t.Errorf("history = %+v", history)
+	}
+	want := Balances{GameTokens: 3, ArtTokens: 4, TechTokens: 10, ArtXP: 10}
+	player, _ := g.Player("0xNew")
+	if player.Balances != want {
+		t.Errorf("balances = %+v, want %+v", player.Balances, want)
+	}
+	if got := Replay(g.Ledger)["0xNew"]; got != want {
+		t.Errorf("replayed balances = %+v, want %+v", got, want)
+	}
+}
+
+func TestRebuildRestoresBalances(t *testing.T) {
+	g := New()
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	g.Players["0xNew"].GameTokens = 1000 // Edited behind the ledger's back
+	if err := g.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if player, _ := g.Player("0xNew"); player.Balances != newPlayerBalances {
+		t.Errorf("balances after rebuild = %+v, want %+v", player.Balances, newPlayerBalances)
+	}
+}
+
+func TestUpgradeAwardsToLedger(t *testing.T) {
+	v1 := `{"Version": 1, "SavedAt": "2024-01-01T00:00:00Z", "Game": {
+		"Players": {"0xA": {"WalletAddress": "0xA", "GameTokens": 7, "ArtXP": 2, "RiddleAttempts": {}, "Role": "player"}},
+		"AllowList": {"0xA": true},
+		"Purgatory": {},
+		"Awards": [{"WalletAddress": "0xA", "GameTokens": 2, "ArtXP": 2, "Time": "2024-01-01T00:00:00Z"}]
+	}}`
+	snapshot, err := DecodeSave([]byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Ledger) != 2 {
+		t.Fatalf("ledger = %+v, want an opening balance and the award", snapshot.Ledger)
+	}
+	if opening := snapshot.Ledger[0]; opening.Reason != "opening balance" || opening.Balances != (Balances{GameTokens: 5}) {
+		t.Errorf("opening balance = %+v", opening)
+	}
+	if got := Replay(snapshot.Ledger)["0xA"]; got != snapshot.Players["0xA"].Balances {
+		t.Errorf("replayed %+v, saved %+v", got, snapshot.Players["0xA"].Balances)
+	}
+}
@@ -0,0 +1,198 @@
+// Description: This file contains the level curves for the Art, Game and Tech tracks and the overall club level, and the token rewards for levelling up. The curves and rewards come from a JSON config file; levels.json is the default.
+
+package game
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Level tracks. Each XP counter has its own track; the club track runs on
+// all XP added together.
+const (
+	TrackArt  = "art"
+	TrackGame = "game"
+	TrackTech = "tech"
+	TrackClub = "club"
+)
+
+// Tracks lists the level tracks in display order.
+var Tracks = []string{TrackArt, TrackGame, TrackTech, TrackClub}
+
+//go:embed levels.json
+var defaultLevelConfig []byte
+
+// LevelConfig is the level curve and rewards of every track.
+type LevelConfig struct {
+	Tracks map[string]LevelTrack `json:"tracks"`
+}
+
+// LevelTrack is one level curve. Thresholds[i] is the XP needed for level
+// i+1, so it starts at 0 and the last entry is the top level.
+type LevelTrack struct {
+	Thresholds []int            `json:"thresholds"`
+	Rewards    map[int]Balances `json:"rewards"` // Tokens granted on reaching a level
+}
+
+// Progress is where a player stands on one track.
+type Progress struct {
+	Level       int
+	XP          int
+	LevelXP     int // XP at which the current level started
+	NextLevelXP int // XP needed for the next level; 0 at the top level
+	XPToNext    int // NextLevelXP minus XP; 0 at the top level
+}
+
+// PlayerLevels is a player's progress on every track, keyed by track name.
+type PlayerLevels map[string]Progress
+
+var ErrInvalidLevelConfig = errors.New("invalid level config")
+
+// DefaultLevelConfig returns the built-in curves from levels.json.
+func DefaultLevelConfig() *LevelConfig {
+	cfg, err := ParseLevelConfig(defaultLevelConfig)
+	if err != nil {
+		panic(err) // levels.json is compiled in; a bad one is a bug
+	}
+	return cfg
+}
+
+// LoadLevelConfig reads level curves and rewards from a JSON file shaped
+// like levels.json.
+func LoadLevelConfig(filename string) (*LevelConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseLevelConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return cfg, nil
+}
+
+// ParseLevelConfig decodes and checks a level config.
+func ParseLevelConfig(data []byte) (*LevelConfig, error) {
+	var cfg LevelConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLevelConfig, err)
+	}
+	for _, name := range Tracks {
+		track, ok := cfg.Tracks[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: missing track %q", ErrInvalidLevelConfig, name)
+		}
+		if len(track.Thresholds) == 0 || track.Thresholds[0] != 0 {
+			return nil, fmt.Errorf("%w: %s thresholds must start at 0", ErrInvalidLevelConfig, name)
+		}
+		for i := 1; i < len(track.Thresholds); i++ {
+			if track.Thresholds[i] <= track.Thresholds[i-1] {
+				return nil, fmt.Errorf("%w: %s thresholds must keep increasing", ErrInvalidLevelConfig, name)
+			}
+		}
+		for level, reward := range track.Rewards {
+			// XP rewards would feed back into the levels they reward.
+			if reward.hasNegative() || reward.ArtXP != 0 || reward.GameXP != 0 || reward.TechXP != 0 {
+				return nil, fmt.Errorf("%w: %s level %d reward must be tokens only", ErrInvalidLevelConfig, name, level)
+			}
+		}
+	}
+	return &cfg, nil
+}
+
+// progress places xp on the track.
+func (t LevelTrack) progress(xp int) Progress {
+	level := 0
+	for level < len(t.Thresholds) && xp >= t.Thresholds[level] {
+		level++
+	}
+	p := Progress{Level: level, XP: xp}
+	if level > 0 {
+		p.LevelXP = t.Thresholds[level-1]
+	}
+	if level < len(t.Thresholds) {
+		p.NextLevelXP = t.Thresholds[level]
+		p.XPToNext = p.NextLevelXP - xp
+	}
+	return p
+}
+
+// trackXP is the XP that counts towards the track.
+func trackXP(balances Balances, track string) int {
+	switch track {
+	case TrackArt:
+		return balances.ArtXP
+	case TrackGame:
+		return balances.GameXP
+	case TrackTech:
+		return balances.TechXP
+	default:
+		return balances.ArtXP + balances.GameXP + balances.TechXP
+	}
+}
+
+// Levels works out the player's progress on every track.
+func (cfg *LevelConfig) Levels(balances Balances) PlayerLevels {
+	levels := make(PlayerLevels, len(Tracks))
+	for _, name := range Tracks {
+		levels[name] = cfg.Tracks[name].progress(trackXP(balances, name))
+	}
+	return levels
+}
+
+// levelUpRewards returns a ledger entry for every level gained between
+// before and after, paying out that level's reward.
+func (cfg *LevelConfig) levelUpRewards(walletAddress string, before, after Balances) []LedgerEntry {
+	var entries []LedgerEntry
+	for _, name := range Tracks {
+		track := cfg.Tracks[name]
+		from := track.progress(trackXP(before, name)).Level
+		to := track.progress(trackXP(after, name)).Level
+		for level := from + 1; level <= to; level++ {
+			reward, ok := track.Rewards[level]
+			if !ok || reward == (Balances{}) {
+				continue
+			}
+			entries = append(entries, LedgerEntry{
+				Actor:         SystemActor,
+				WalletAddress: walletAddress,
+				Balances:      reward,
+				Reason:        fmt.Sprintf("reached %s level %d", name, level),
+			})
+		}
+	}
+	return entries
+}
+
+// SetLevelConfig swaps in new level curves and rewards. Levels already
+// reached are not paid out again.
+func (g *Game) SetLevelConfig(cfg *LevelConfig) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.levels = cfg
+}
+
+// Levels returns the player's progress on every track.
+func (g *Game) Levels(walletAddress string) (PlayerLevels, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return nil, false
+	}
+	return g.levelConfig().Levels(player.Balances), true
+}
+
+// levelConfig returns the game's level config, falling back to the
+// default. The caller must hold g.mu.
+func (g *Game) levelConfig() *LevelConfig {
+	if g.levels == nil {
+		return defaultLevels
+	}
+	return g.levels
+}
+
+var defaultLevels = DefaultLevelConfig()
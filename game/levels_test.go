@@ -0,0 +1,92 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+const testLevels = `{"tracks": {
+	"art":  {"thresholds": [0, 10, 30], "rewards": {"2": {"ArtTokens": 1}, "3": {"ArtTokens": 2}}},
+	"game": {"thresholds": [0, 10]},
+	"tech": {"thresholds": [0, 10]},
+	"club": {"thresholds": [0, 20], "rewards": {"2": {"GameTokens": 5}}}
+}}`
+
+func TestProgress(t *testing.T) {
+	cfg, err := ParseLevelConfig([]byte(testLevels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		xp   int
+		want Progress
+	}{
+		{0, Progress{Level: 1, XP: 0, LevelXP: 0, NextLevelXP: 10, XPToNext: 10}},
+		{12, Progress{Level: 2, XP: 12, LevelXP: 10, NextLevelXP: 30, XPToNext: 18}},
+		{30, Progress{Level: 3, XP: 30, LevelXP: 30}},
+		{500, Progress{Level: 3, XP: 500, LevelXP: 30}},
+	} {
+		if got := cfg.Levels(Balances{ArtXP: tc.xp})[TrackArt]; got != tc.want {
+			t.Errorf("art progress at %d XP = %+v, want %+v", tc.xp, got, tc.want)
+		}
+	}
+	if got := cfg.Levels(Balances{ArtXP: 5, GameXP: 10, TechXP: 5})[TrackClub].Level; got != 2 {
+		t.Errorf("club level = %d, want 2", got)
+	}
+}
+
+func TestLevelUpRewards(t *testing.T) {
+	cfg, err := ParseLevelConfig([]byte(testLevels))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	g.SetLevelConfig(cfg)
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	// Straight to art level 3 and club level 2: every level on the way pays out.
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{ArtXP: 35}, "mural"); err != nil {
+		t.Fatal(err)
+	}
+	player, _ := g.Player("0xNew")
+	want := newPlayerBalances.Add(Balances{ArtTokens: 3, GameTokens: 5, ArtXP: 35})
+	if player.Balances != want {
+		t.Errorf("balances = %+v, want %+v", player.Balances, want)
+	}
+	var reasons []string
+	for _, entry := range g.History("0xNew") {
+		reasons = append(reasons, entry.Reason)
+	}
+	wantReasons := []string{"starting balance", "mural", "reached art level 2", "reached art level 3", "reached club level 2"}
+	if len(reasons) != len(wantReasons) {
+		t.Fatalf("history reasons = %q, want %q", reasons, wantReasons)
+	}
+	for i := range reasons {
+		if reasons[i] != wantReasons[i] {
+			t.Errorf("history reasons = %q, want %q", reasons, wantReasons)
+			break
+		}
+	}
+
+	// No second payout for a level already reached.
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xNew", Balances{ArtXP: 1}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := g.Player("0xNew"); after.ArtTokens != player.ArtTokens {
+		t.Errorf("art tokens went from %d to %d without a level up", player.ArtTokens, after.ArtTokens)
+	}
+}
+
+func TestParseLevelConfigRejectsBadCurves(t *testing.T) {
+	for name, config := range map[string]string{
+		"missing track":  `{"tracks": {"art": {"thresholds": [0]}}}`,
+		"not from zero":  `{"tracks": {"art": {"thresholds": [5]}, "game": {"thresholds": [0]}, "tech": {"thresholds": [0]}, "club": {"thresholds": [0]}}}`,
+		"not increasing": `{"tracks": {"art": {"thresholds": [0, 10, 10]}, "game": {"thresholds": [0]}, "tech": {"thresholds": [0]}, "club": {"thresholds": [0]}}}`,
+		"xp reward":      `{"tracks": {"art": {"thresholds": [0, 10], "rewards": {"2": {"ArtXP": 5}}}, "game": {"thresholds": [0]}, "tech": {"thresholds": [0]}, "club": {"thresholds": [0]}}}`,
+	} {
+		if _, err := ParseLevelConfig([]byte(config)); !errors.Is(err, ErrInvalidLevelConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidLevelConfig", name, err)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Description: This file contains player-to-player token transfers and the marketplace. Every step goes through the ledger: an offer's tokens are held in escrow from the moment it is posted, so accepting it can never bounce.
+
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Token is one of the three tradeable token types. XP can't be traded.
+type Token string
+
+const (
+	TokenGame Token = "game"
+	TokenArt  Token = "art"
+	TokenTech Token = "tech"
+)
+
+type OfferStatus string
+
+const (
+	OfferOpen      OfferStatus = "open"
+	OfferAccepted  OfferStatus = "accepted"
+	OfferCancelled OfferStatus = "cancelled"
+)
+
+// Offer is a marketplace listing: the seller gives GiveAmount of Give in
+// exchange for WantAmount of Want.
+type Offer struct {
+	ID         int
+	Seller     string
+	Give       Token
+	GiveAmount int
+	Want       Token
+	WantAmount int
+	Status     OfferStatus
+	Buyer      string // Set once accepted
+	CreatedAt  time.Time
+	ClosedAt   time.Time // Zero while open
+}
+
+var (
+	ErrUnknownToken   = errors.New("unknown token type (options: game, art, tech)")
+	ErrInvalidAmount  = errors.New("amount must be a positive whole number")
+	ErrSelfTrade      = errors.New("you can't trade with yourself")
+	ErrOfferNotFound  = errors.New("offer not found")
+	ErrOfferClosed    = errors.New("offer is no longer open")
+	ErrNotOfferSeller = errors.New("only the seller can cancel an offer")
+	ErrSameTokenOffer = errors.New("an offer must swap two different token types")
+)
+
+// ParseToken turns user input such as "Art" into a Token.
+func ParseToken(s string) (Token, error) {
+	switch token := Token(strings.ToLower(s)); token {
+	case TokenGame, TokenArt, TokenTech:
+		return token, nil
+	}
+	return "", ErrUnknownToken
+}
+
+// amount returns a change of n tokens of this type.
+func (t Token) amount(n int) Balances {
+	switch t {
+	case TokenGame:
+		return Balances{GameTokens: n}
+	case TokenArt:
+		return Balances{ArtTokens: n}
+	default:
+		return Balances{TechTokens: n}
+	}
+}
+
+// Transfer moves tokens from one player to another. Both sides are recorded
+// in one ledger batch, so a transfer either happens completely or not at all.
+func (g *Game) Transfer(from, to string, token Token, amount int) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if from == to {
+		return ErrSelfTrade
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.post(
+		LedgerEntry{Actor: from, WalletAddress: from, Balances: token.amount(-amount), Reason: "transfer to " + to},
+		LedgerEntry{Actor: from, WalletAddress: to, Balances: token.amount(amount), Reason: "transfer from " + from},
+	)
+}
+
+// PostOffer lists an offer and moves the tokens on offer out of the seller's
+// balance into escrow until the offer is accepted or cancelled.
+func (g *Game) PostOffer(seller string, give Token, giveAmount int, want Token, wantAmount int) (Offer, error) {
+	if giveAmount <= 0 || wantAmount <= 0 {
+		return Offer{}, ErrInvalidAmount
+	}
+	if give == want {
+		return Offer{}, ErrSameTokenOffer
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	offer := &Offer{
+		ID:         g.nextOfferID(),
+		Seller:     seller,
+		Give:       give,
+		GiveAmount: giveAmount,
+		Want:       want,
+		WantAmount: wantAmount,
+		Status:     OfferOpen,
+		CreatedAt:  time.Now().UTC(),
+	}
+	escrow := LedgerEntry{Actor: seller, WalletAddress: seller, Balances: give.amount(-giveAmount), Reason: offer.describe("escrow for")}
+	if err := g.recordOffer(offer, escrow); err != nil {
+		return Offer{}, err
+	}
+	return *offer, nil
+}
+
+// AcceptOffer pays the seller from the buyer's balance and releases the
+// escrowed tokens to the buyer.
+func (g *Game) AcceptOffer(buyer string, id int) (Offer, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	offer, err := g.openOffer(id)
+	if err != nil {
+		return Offer{}, err
+	}
+	if offer.Seller == buyer {
+		return Offer{}, ErrSelfTrade
+	}
+	accepted := *offer
+	accepted.Status = OfferAccepted
+	accepted.Buyer = buyer
+	accepted.ClosedAt = time.Now().UTC()
+	reason := offer.describe("accepted")
+	err = g.recordOffer(&accepted,
+		LedgerEntry{Actor: buyer, WalletAddress: buyer, Balances: offer.Want.amount(-offer.WantAmount), Reason: reason},
+		LedgerEntry{Actor: buyer, WalletAddress: offer.Seller, Balances: offer.Want.amount(offer.WantAmount), Reason: reason},
+		LedgerEntry{Actor: buyer, WalletAddress: buyer, Balances: offer.Give.amount(offer.GiveAmount), Reason: reason},
+	)
+	if err != nil {
+		return Offer{}, err
+	}
+	return accepted, nil
+}
+
+// CancelOffer withdraws an open offer and returns the escrowed tokens to
+// the seller. Only the seller may cancel.
+func (g *Game) CancelOffer(actor string, id int) (Offer, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	offer, err := g.openOffer(id)
+	if err != nil {
+		return Offer{}, err
+	}
+	if offer.Seller != actor {
+		return Offer{}, ErrNotOfferSeller
+	}
+	cancelled := *offer
+	cancelled.Status = OfferCancelled
+	cancelled.ClosedAt = time.Now().UTC()
+	refund := LedgerEntry{Actor: actor, WalletAddress: offer.Seller, Balances: offer.Give.amount(offer.GiveAmount), Reason: offer.describe("cancelled")}
+	if err := g.recordOffer(&cancelled, refund); err != nil {
+		return Offer{}, err
+	}
+	return cancelled, nil
+}
+
+// cancelOffersBy cancels every open offer from the seller, refunding the
+// escrow, for when the seller leaves the game and can no longer cancel them
+// or be paid. The caller must hold g.mu.
+func (g *Game) cancelOffersBy(actor, seller string) error {
+	ids := make([]int, 0, len(g.Offers))
+	for id, offer := range g.Offers {
+		if offer.Seller == seller && offer.Status == OfferOpen {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		offer := g.Offers[id]
+		cancelled := *offer
+		cancelled.Status = OfferCancelled
+		cancelled.ClosedAt = time.Now().UTC()
+		refund := LedgerEntry{Actor: actor, WalletAddress: seller, Balances: offer.Give.amount(offer.GiveAmount), Reason: offer.describe("seller removed, cancelled")}
+		if err := g.recordOffer(&cancelled, refund); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenOffers returns copies of the offers that can still be accepted, oldest first.
+func (g *Game) OpenOffers() []Offer {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var offers []Offer
+	for _, offer := range g.Offers {
+		if offer.Status == OfferOpen {
+			offers = append(offers, *offer)
+		}
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].ID < offers[j].ID })
+	return offers
+}
+
+// openOffer looks up an offer that can still be accepted or cancelled. The
+// caller must hold g.mu.
+func (g *Game) openOffer(id int) (*Offer, error) {
+	offer, exists := g.Offers[id]
+	if !exists {
+		return nil, ErrOfferNotFound
+	}
+	if offer.Status != OfferOpen {
+		return nil, ErrOfferClosed
+	}
+	return offer, nil
+}
+
+// nextOfferID returns one more than the highest ID so far. The caller must hold g.mu.
+func (g *Game) nextOfferID() int {
+	id := 0
+	for existing := range g.Offers {
+		if existing > id {
+			id = existing
+		}
+	}
+	return id + 1
+}
+
+// recordOffer stores the offer together with the ledger entries that move
+// its tokens, in one transaction, then applies both. The caller must hold g.mu.
+func (g *Game) recordOffer(offer *Offer, entries ...LedgerEntry) error {
+	after, err := g.balancesAfter(entries)
+	if err != nil {
+		return err
+	}
+	stamp(entries)
+	if g.store != nil {
+		if err := g.store.PutOffer(offer, entries...); err != nil {
+			return err
+		}
+	}
+	g.Offers[offer.ID] = offer
+	g.Ledger = append(g.Ledger, entries...)
+	return g.applyBalances(after)
+}
+
+// describe names the offer for ledger reasons, e.g. "escrow for offer #2 (3 art for 5 tech)".
+func (o *Offer) describe(prefix string) string {
+	return fmt.Sprintf("%s offer #%d (%d %s for %d %s)", prefix, o.ID, o.GiveAmount, o.Give, o.WantAmount, o.Want)
+}
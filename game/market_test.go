@@ -0,0 +1,149 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func newMarketGame(t *testing.T) *Game {
+	t.Helper()
+	g := New()
+	for _, walletAddress := range []string{"0xSeller", "0xBuyer"} {
+		if err := g.AddPlayer(walletAddress, walletAddress); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+func balancesOf(t *testing.T, g *Game, walletAddress string) Balances {
+	t.Helper()
+	player, ok := g.Player(walletAddress)
+	if !ok {
+		t.Fatalf("%s not found", walletAddress)
+	}
+	return player.Balances
+}
+
+func TestTransfer(t *testing.T) {
+	g := newMarketGame(t)
+	if err := g.Transfer("0xSeller", "0xBuyer", TokenTech, 4); err != nil {
+		t.Fatal(err)
+	}
+	if got := balancesOf(t, g, "0xSeller").TechTokens; got != 6 {
+		t.Errorf("sender has %d tech tokens, want 6", got)
+	}
+	if got := balancesOf(t, g, "0xBuyer").TechTokens; got != 14 {
+		t.Errorf("receiver has %d tech tokens, want 14", got)
+	}
+
+	before := len(g.Ledger)
+	for _, tc := range []struct {
+		to     string
+		amount int
+		want   error
+	}{
+		{"0xBuyer", 100, ErrInsufficientBalance},
+		{"0xBuyer", -1, ErrInvalidAmount},
+		{"0xSeller", 1, ErrSelfTrade},
+		{"0xNobody", 1, ErrPlayerNotFound},
+	} {
+		if err := g.Transfer("0xSeller", tc.to, TokenTech, tc.amount); !errors.Is(err, tc.want) {
+			t.Errorf("transfer %d to %s: err = %v, want %v", tc.amount, tc.to, err, tc.want)
+		}
+	}
+	if len(g.Ledger) != before {
+		t.Errorf("failed transfers left %d ledger entries behind", len(g.Ledger)-before)
+	}
+	if got := balancesOf(t, g, "0xSeller").TechTokens; got != 6 {
+		t.Errorf("failed transfers changed the sender's tech tokens to %d", got)
+	}
+}
+
+func TestOfferAccept(t *testing.T) {
+	g := newMarketGame(t)
+	offer, err := g.PostOffer("0xSeller", TokenArt, 1, TokenTech, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := balancesOf(t, g, "0xSeller").ArtTokens; got != 0 {
+		t.Errorf("seller still has %d art tokens while they are in escrow", got)
+	}
+	if _, err := g.AcceptOffer("0xSeller", offer.ID); !errors.Is(err, ErrSelfTrade) {
+		t.Errorf("seller accepting own offer: err = %v, want ErrSelfTrade", err)
+	}
+
+	accepted, err := g.AcceptOffer("0xBuyer", offer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepted.Status != OfferAccepted || accepted.Buyer != "0xBuyer" {
+		t.Errorf("accepted offer = %+v", accepted)
+	}
+	if got, want := balancesOf(t, g, "0xSeller"), (Balances{GameTokens: 5, ArtTokens: 0, TechTokens: 15}); got != want {
+		t.Errorf("seller balances = %+v, want %+v", got, want)
+	}
+	if got, want := balancesOf(t, g, "0xBuyer"), (Balances{GameTokens: 5, ArtTokens: 2, TechTokens: 5}); got != want {
+		t.Errorf("buyer balances = %+v, want %+v", got, want)
+	}
+	if _, err := g.AcceptOffer("0xBuyer", offer.ID); !errors.Is(err, ErrOfferClosed) {
+		t.Errorf("accepting twice: err = %v, want ErrOfferClosed", err)
+	}
+	if len(g.OpenOffers()) != 0 {
+		t.Errorf("open offers = %+v, want none", g.OpenOffers())
+	}
+	// The escrow is the only thing the ledger can't see; once the offer is
+	// closed, replaying it must agree with the players.
+	if err := g.Rebuild(); err != nil {
+		t.Fatal(err)
+	}
+	if got := balancesOf(t, g, "0xBuyer").TechTokens; got != 5 {
+		t.Errorf("buyer has %d tech tokens after rebuild, want 5", got)
+	}
+}
+
+func TestOfferCancel(t *testing.T) {
+	g := newMarketGame(t)
+	if _, err := g.PostOffer("0xSeller", TokenArt, 2, TokenTech, 1); !errors.Is(err, ErrInsufficientBalance) {
+		t.Errorf("offering more than the seller has: err = %v, want ErrInsufficientBalance", err)
+	}
+	offer, err := g.PostOffer("0xSeller", TokenGame, 3, TokenArt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.CancelOffer("0xBuyer", offer.ID); !errors.Is(err, ErrNotOfferSeller) {
+		t.Errorf("buyer cancelling: err = %v, want ErrNotOfferSeller", err)
+	}
+	if _, err := g.CancelOffer("0xSeller", offer.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := balancesOf(t, g, "0xSeller").GameTokens; got != 5 {
+		t.Errorf("seller has %d game tokens after cancelling, want 5", got)
+	}
+	if _, err := g.AcceptOffer("0xBuyer", offer.ID); !errors.Is(err, ErrOfferClosed) {
+		t.Errorf("accepting a cancelled offer: err = %v, want ErrOfferClosed", err)
+	}
+}
+
+func TestRemovingSellerCancelsOffers(t *testing.T) {
+	g := newMarketGame(t)
+	offer, err := g.PostOffer("0xSeller", TokenGame, 3, TokenArt, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePlayer(tippiWalletAddress, "0xSeller", "left the club"); err != nil {
+		t.Fatal(err)
+	}
+	if offers := g.OpenOffers(); len(offers) != 0 {
+		t.Errorf("open offers after removing the seller = %+v", offers)
+	}
+	if closed := g.Offers[offer.ID]; closed.Status != OfferCancelled {
+		t.Errorf("offer status = %s, want cancelled", closed.Status)
+	}
+	if err := g.RestorePlayer("0xSeller"); err != nil {
+		t.Fatal(err)
+	}
+	if got := balancesOf(t, g, "0xSeller").GameTokens; got != 5 {
+		t.Errorf("seller has %d game tokens after removal and restore, want the escrow back: 5", got)
+	}
+}
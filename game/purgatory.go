@@ -0,0 +1,148 @@
+// Description: This file contains the Purgatory lifecycle. Removed players wait in Purgatory with their balances intact, together with the reason they were removed and any appeal, until they are restored or, once they have been there long enough, purged for good.
+
+package game
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Removal records why a player is in Purgatory.
+type Removal struct {
+	RemovedBy string    // Wallet of whoever removed the player
+	RemovedAt time.Time // Zero for players removed before removals were recorded
+	Reason    string
+	Appeal    string // The player's side of the story, if they have appealed
+}
+
+var (
+	ErrNotInPurgatory = errors.New("player is not in Purgatory")
+	ErrInPurgatory    = errors.New("player is in Purgatory; restore them instead")
+)
+
+// PurgatoryPlayers returns copies of the players in Purgatory, longest
+// removed first.
+func (g *Game) PurgatoryPlayers() []Player {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	players := make([]Player, 0, len(g.Purgatory))
+	for _, player := range g.Purgatory {
+		players = append(players, player.clone())
+	}
+	sort.Slice(players, func(i, j int) bool {
+		a, b := players[i].removedAt(), players[j].removedAt()
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return players[i].WalletAddress < players[j].WalletAddress
+	})
+	return players
+}
+
+// RestorePlayer moves a player out of Purgatory and back into the game and
+// the allow list, with the balances they had when they were removed.
+func (g *Game) RestorePlayer(walletAddress string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	player, exists := g.Purgatory[walletAddress]
+	if !exists {
+		return ErrNotInPurgatory
+	}
+	if _, exists := g.Players[walletAddress]; exists {
+		return ErrPlayerExists // Re-added under the same wallet since
+	}
+	restored := player.Clone()
+	restored.Removal = nil
+	if g.store != nil {
+		if err := g.store.PutPlayer(restored.Clone()); err != nil {
+			return err
+		}
+		if err := g.store.SetAllowed(walletAddress, true); err != nil {
+			return err
+		}
+		if err := g.store.DeletePurgatory(walletAddress); err != nil {
+			return err
+		}
+	}
+	g.Players[walletAddress] = restored
+	g.AllowList[walletAddress] = true
+	delete(g.Purgatory, walletAddress)
+	return nil
+}
+
+// AppealRemoval records the player's appeal against their removal,
+// replacing any earlier one.
+func (g *Game) AppealRemoval(walletAddress, appeal string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	player, exists := g.Purgatory[walletAddress]
+	if !exists {
+		return ErrNotInPurgatory
+	}
+	updated := player.Clone()
+	if updated.Removal == nil {
+		updated.Removal = &Removal{}
+	}
+	updated.Removal.Appeal = appeal
+	if g.store != nil {
+		if err := g.store.PutPurgatory(updated.Clone()); err != nil {
+			return err
+		}
+	}
+	g.Purgatory[walletAddress] = updated
+	return nil
+}
+
+// PurgeExpired deletes, for good, the players who have been in Purgatory
+// for longer than maxAge, and returns their wallet addresses. Players
+// whose removal time is unknown are kept. Their ledger entries stay, so a
+// purged wallet that is added again starts from what it had.
+func (g *Game) PurgeExpired(maxAge time.Duration) ([]string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cutoff := time.Now().UTC().Add(-maxAge)
+	var purged []string
+	for walletAddress, player := range g.Purgatory {
+		removedAt := player.removedAt()
+		if removedAt.IsZero() || !removedAt.Before(cutoff) {
+			continue
+		}
+		if g.store != nil {
+			if err := g.store.DeletePurgatory(walletAddress); err != nil {
+				return purged, err
+			}
+		}
+		delete(g.Purgatory, walletAddress)
+		purged = append(purged, walletAddress)
+	}
+	sort.Strings(purged)
+	return purged, nil
+}
+
+// AutoPurge runs PurgeExpired now and then every interval until ctx is
+// done, passing each purge to report. report may be nil.
+func (g *Game) AutoPurge(ctx context.Context, maxAge, interval time.Duration, report func(purged []string, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := g.PurgeExpired(maxAge)
+		if report != nil && (len(purged) > 0 || err != nil) {
+			report(purged, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// removedAt is when the player was moved to Purgatory, or zero if unknown.
+func (p *Player) removedAt() time.Time {
+	if p.Removal == nil {
+		return time.Time{}
+	}
+	return p.Removal.RemovedAt
+}
@@ -0,0 +1,84 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPurgatoryLifecycle(t *testing.T) {
+	g := New()
+	if err := g.AddPlayer("0xGone", "Gone"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AwardTokensXP(tippiWalletAddress, "0xGone", Balances{ArtXP: 40}, "painted the van"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePlayer(tippiWalletAddress, "0xGone", "spamming the chat"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AppealRemoval("0xGone", "it was my cat"); err != nil {
+		t.Fatal(err)
+	}
+	ledger := len(g.Ledger)
+	if err := g.AddPlayer("0xGone", "Gone Again"); !errors.Is(err, ErrInPurgatory) {
+		t.Errorf("adding a player in Purgatory: err = %v", err)
+	}
+	if _, ok := g.Player("0xGone"); ok || len(g.Ledger) != ledger {
+		t.Error("adding a player in Purgatory brought them back or paid them again")
+	}
+
+	players := g.PurgatoryPlayers()
+	if len(players) != 1 || players[0].Removal == nil {
+		t.Fatalf("purgatory = %+v", players)
+	}
+	removal := *players[0].Removal
+	if removal.RemovedBy != tippiWalletAddress || removal.Reason != "spamming the chat" || removal.Appeal != "it was my cat" || removal.RemovedAt.IsZero() {
+		t.Errorf("removal = %+v", removal)
+	}
+
+	if err := g.RestorePlayer("0xGone"); err != nil {
+		t.Fatal(err)
+	}
+	player, ok := g.Player("0xGone")
+	if !ok || !g.IsAllowed("0xGone") || len(g.PurgatoryPlayers()) != 0 {
+		t.Fatal("0xGone was not restored")
+	}
+	if player.ArtXP != 40 || player.Removal != nil {
+		t.Errorf("restored player = %+v", player)
+	}
+	if err := g.RestorePlayer("0xGone"); !errors.Is(err, ErrNotInPurgatory) {
+		t.Errorf("restoring twice: err = %v", err)
+	}
+	if err := g.AppealRemoval("0xGone", "again"); !errors.Is(err, ErrNotInPurgatory) {
+		t.Errorf("appeal outside Purgatory: err = %v", err)
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	g := New()
+	for _, walletAddress := range []string{"0xOld", "0xNew", "0xUndated"} {
+		if err := g.AddPlayer(walletAddress, walletAddress); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.RemovePlayer(tippiWalletAddress, walletAddress, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.Purgatory["0xOld"].Removal.RemovedAt = time.Now().UTC().AddDate(0, 0, -31)
+	g.Purgatory["0xUndated"].Removal = &Removal{}
+
+	purged, err := g.PurgeExpired(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purged) != 1 || purged[0] != "0xOld" {
+		t.Errorf("purged = %v, want [0xOld]", purged)
+	}
+	if _, ok := g.Purgatory["0xNew"]; !ok {
+		t.Error("0xNew was purged before its time")
+	}
+	if _, ok := g.Purgatory["0xUndated"]; !ok {
+		t.Error("0xUndated was purged without a removal time")
+	}
+}
@@ -0,0 +1,447 @@
+// Description: This file contains the riddle bank: the riddles, how their answers are matched and the policy on attempts, hints and rewards. The riddles come from YAML or JSON files, so gamemasters can add their own without recompiling; riddles.yaml is the default.
+
+package game
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed riddles.yaml
+var defaultRiddleBank []byte
+
+// Riddle is one riddle in the bank.
+type Riddle struct {
+	ID         string     `yaml:"id"`
+	Language   string     `yaml:"language"` // Or topic
+	Prompt     string     `yaml:"prompt"`
+	Code       string     `yaml:"code"` // Optional snippet shown under the prompt
+	Difficulty Difficulty `yaml:"difficulty"`
+	Answer     Matcher    `yaml:"answer" json:"-"` // Never sent to players
+	Hints      []string   `yaml:"hints" json:"-"`  // Revealed one at a time, for a price
+	Reward     Balances   `yaml:"reward"`          // Before the difficulty bonus; see Payout
+	Correct    string     `yaml:"correct"`         // Shown for a right answer
+	Wrong      string     `yaml:"wrong"`           // Shown for a wrong answer
+
+	RiddlePolicy `yaml:",inline"` // Tries, cooldown, penalty and hint cost
+}
+
+// RiddlePolicy is how many tries a riddle allows and what hints and wrong
+// answers cost. The defaults at the top of a bank file apply to every
+// riddle in it, and a riddle can override any of them.
+type RiddlePolicy struct {
+	MaxAttempts int           `yaml:"max_attempts"` // 0 means unlimited
+	Cooldown    time.Duration `yaml:"cooldown"`     // Wait after a wrong answer, e.g. "30s"
+	Penalty     int           `yaml:"penalty"`      // Percent of the payout lost per wrong answer
+	HintCost    Balances      `yaml:"hint_cost"`    // Tokens paid for each hint
+}
+
+// Difficulty is a riddle's tier. Players get the easy riddles of a topic
+// first, and harder ones pay more XP.
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
+// Difficulties lists the tiers from easiest to hardest. A riddle's XP
+// reward is multiplied by its tier's place in the list, counting from 1.
+var Difficulties = []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// MatchKind is how an answer is compared with the accepted ones.
+type MatchKind string
+
+const (
+	MatchExact           MatchKind = "exact"
+	MatchCaseInsensitive MatchKind = "case-insensitive"
+	MatchRegex           MatchKind = "regex"
+	MatchContains        MatchKind = "contains" // Mentions the keyword, ignoring case
+	MatchGo              MatchKind = "go"       // Go code that passes the hidden tests; see sandbox.go
+)
+
+// Matcher decides whether an answer is right. Any one of Accept will do,
+// except for go answers, which must pass Tests instead.
+type Matcher struct {
+	Match    MatchKind     `yaml:"match"` // Empty means exact
+	Accept   []string      `yaml:"accept"`
+	Tests    string        `yaml:"tests"`     // A _test.go file in package riddle, for go answers
+	Timeout  time.Duration `yaml:"timeout"`   // For running go answers; 10s if not set
+	Memory   int           `yaml:"memory_mb"` // For running go answers; 256 if not set
+	patterns []*regexp.Regexp
+}
+
+// RiddleBank is a set of riddles, in the order they are offered.
+type RiddleBank struct {
+	Riddles []Riddle `yaml:"riddles"`
+	byID    map[string]int
+}
+
+var (
+	ErrUnknownRiddle     = errors.New("unknown riddle")
+	ErrInvalidRiddleBank = errors.New("invalid riddle bank")
+	validRiddleID        = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	riddleBankExtensions = []string{".yaml", ".yml", ".json"}
+	defaultRiddles       = DefaultRiddleBank()
+)
+
+// DefaultRiddleBank returns the built-in riddles from riddles.yaml.
+func DefaultRiddleBank() *RiddleBank {
+	riddles, err := parseRiddles(defaultRiddleBank)
+	if err != nil {
+		panic(err) // riddles.yaml is compiled in; a bad one is a bug
+	}
+	bank, err := NewRiddleBank(riddles)
+	if err != nil {
+		panic(err)
+	}
+	return bank
+}
+
+// LoadRiddleBank reads riddles from a YAML or JSON file shaped like
+// riddles.yaml, or from every such file in a directory, in name order.
+func LoadRiddleBank(path string) (*RiddleBank, error) {
+	files := []string{path}
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = nil
+		for _, entry := range entries {
+			for _, ext := range riddleBankExtensions {
+				if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+	var riddles []Riddle
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		more, err := parseRiddles(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		riddles = append(riddles, more...)
+	}
+	bank, err := NewRiddleBank(riddles)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bank, nil
+}
+
+// parseRiddles decodes one riddle file. JSON is valid YAML, so both go
+// through the YAML decoder; see Balances.UnmarshalYAML for JSON field names.
+func parseRiddles(data []byte) ([]Riddle, error) {
+	var file struct {
+		Defaults RiddlePolicy `yaml:"defaults"`
+		Riddles  []yaml.Node  `yaml:"riddles"`
+	}
+	if err := decodeYAML(data, &file); err != nil {
+		return nil, err
+	}
+	riddles := make([]Riddle, len(file.Riddles))
+	for i := range file.Riddles {
+		// Decoding over the defaults keeps the ones the riddle leaves out.
+		riddles[i].RiddlePolicy = file.Defaults
+		data, err := yaml.Marshal(&file.Riddles[i])
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeYAML(data, &riddles[i]); err != nil {
+			return nil, err
+		}
+	}
+	return riddles, nil
+}
+
+func decodeYAML(data []byte, v interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true) // Catch misspelt fields rather than ignore them
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRiddleBank, err)
+	}
+	return nil
+}
+
+// UnmarshalYAML reads balances from a riddle file. The field names match
+// in any case, so "gamexp" works as well as the "GameXP" of saves and the
+// API, and rewards can be copied from either.
+func (b *Balances) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: balances must be a mapping", node.Line)
+	}
+	fields := map[string]*int{
+		"gametokens": &b.GameTokens,
+		"arttokens":  &b.ArtTokens,
+		"techtokens": &b.TechTokens,
+		"artxp":      &b.ArtXP,
+		"gamexp":     &b.GameXP,
+		"techxp":     &b.TechXP,
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		field, ok := fields[strings.ToLower(key.Value)]
+		if !ok {
+			return fmt.Errorf("line %d: field %s not found in balances", key.Line, key.Value)
+		}
+		if err := value.Decode(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRiddleBank checks the riddles and makes a bank of them.
+func NewRiddleBank(riddles []Riddle) (*RiddleBank, error) {
+	if len(riddles) == 0 {
+		return nil, fmt.Errorf("%w: no riddles", ErrInvalidRiddleBank)
+	}
+	bank := &RiddleBank{byID: make(map[string]int, len(riddles))}
+	for _, riddle := range riddles {
+		if !validRiddleID.MatchString(riddle.ID) {
+			return nil, fmt.Errorf("%w: riddle id %q must be letters, digits, '.', '_' or '-'", ErrInvalidRiddleBank, riddle.ID)
+		}
+		if _, taken := bank.byID[riddle.ID]; taken {
+			return nil, fmt.Errorf("%w: riddle %s appears twice", ErrInvalidRiddleBank, riddle.ID)
+		}
+		if err := riddle.check(); err != nil {
+			return nil, fmt.Errorf("%w: riddle %s: %v", ErrInvalidRiddleBank, riddle.ID, err)
+		}
+		bank.byID[riddle.ID] = len(bank.Riddles)
+		bank.Riddles = append(bank.Riddles, riddle)
+	}
+	return bank, nil
+}
+
+// check fills in defaults and compiles the answer patterns.
+func (r *Riddle) check() error {
+	if r.Language == "" || r.Prompt == "" {
+		return errors.New("needs a language and a prompt")
+	}
+	if r.Reward.hasNegative() {
+		return errors.New("reward can't be negative")
+	}
+	if r.Difficulty == "" {
+		r.Difficulty = DifficultyEasy
+	} else if r.Difficulty.tier() == 0 {
+		return fmt.Errorf("unknown difficulty %q", r.Difficulty)
+	}
+	if r.MaxAttempts < 0 || r.Cooldown < 0 || r.Penalty < 0 || r.Penalty > 100 {
+		return errors.New("max_attempts and cooldown can't be negative, and penalty must be 0 to 100")
+	}
+	if r.HintCost.hasNegative() || r.HintCost.ArtXP != 0 || r.HintCost.GameXP != 0 || r.HintCost.TechXP != 0 {
+		return errors.New("hint_cost must be tokens only")
+	}
+	return r.Answer.compile()
+}
+
+// tier is 1 for easy, 2 for medium and 3 for hard, or 0 if unknown.
+func (d Difficulty) tier() int {
+	for i, difficulty := range Difficulties {
+		if d == difficulty {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Payout is what solving the riddle at the first try pays: the reward,
+// with its XP multiplied by the difficulty tier.
+func (r Riddle) Payout() Balances {
+	payout, tier := r.Reward, r.Difficulty.tier()
+	payout.ArtXP *= tier
+	payout.GameXP *= tier
+	payout.TechXP *= tier
+	return payout
+}
+
+// PayoutAfter is what solving the riddle pays after some wrong answers:
+// Penalty percent less for each, but never less than nothing.
+func (r Riddle) PayoutAfter(wrong int) Balances {
+	return r.Payout().percent(max(0, 100-r.Penalty*wrong))
+}
+
+// percent scales every value to p percent, rounding towards zero.
+func (b Balances) percent(p int) Balances {
+	return Balances{
+		GameTokens: b.GameTokens * p / 100,
+		ArtTokens:  b.ArtTokens * p / 100,
+		TechTokens: b.TechTokens * p / 100,
+		ArtXP:      b.ArtXP * p / 100,
+		GameXP:     b.GameXP * p / 100,
+		TechXP:     b.TechXP * p / 100,
+	}
+}
+
+func (m *Matcher) compile() error {
+	if m.Match == MatchGo {
+		return m.compileGo()
+	}
+	if m.Tests != "" || m.Timeout != 0 || m.Memory != 0 {
+		return errors.New("tests, timeout and memory_mb are only for go answers")
+	}
+	if len(m.Accept) == 0 {
+		return errors.New("needs at least one accepted answer")
+	}
+	switch m.Match {
+	case "":
+		m.Match = MatchExact
+	case MatchExact, MatchCaseInsensitive, MatchContains:
+	case MatchRegex:
+		m.patterns = make([]*regexp.Regexp, len(m.Accept))
+		for i, accept := range m.Accept {
+			pattern, err := regexp.Compile(accept)
+			if err != nil {
+				return err
+			}
+			m.patterns[i] = pattern
+		}
+	default:
+		return fmt.Errorf("unknown match %q", m.Match)
+	}
+	return nil
+}
+
+func (m *Matcher) compileGo() error {
+	if len(m.Accept) != 0 {
+		return errors.New("go answers are checked by tests, not accept")
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "tests", m.Tests, parser.PackageClauseOnly)
+	if err != nil || file.Name.Name != sandboxPackage {
+		return fmt.Errorf("tests must be a Go test file in package %s", sandboxPackage)
+	}
+	if m.Timeout < 0 || m.Memory < 0 {
+		return errors.New("timeout and memory_mb can't be negative")
+	}
+	if m.Timeout == 0 {
+		m.Timeout = defaultRunTimeout
+	}
+	if m.Memory == 0 {
+		m.Memory = defaultMemoryMB
+	}
+	return nil
+}
+
+// Check reports whether the answer is right, building and testing go
+// answers. The output explains a wrong go answer.
+func (m Matcher) Check(answer string) (correct bool, output string, err error) {
+	if m.Match == MatchGo {
+		return m.checkGo(answer)
+	}
+	return m.Matches(answer), "", nil
+}
+
+// Matches reports whether the answer, without surrounding spaces, is
+// right. It never accepts go answers, which need Check.
+func (m Matcher) Matches(answer string) bool {
+	answer = strings.TrimSpace(answer)
+	for i, accept := range m.Accept {
+		var ok bool
+		switch m.Match {
+		case MatchCaseInsensitive:
+			ok = strings.EqualFold(answer, accept)
+		case MatchRegex:
+			ok = m.patterns[i].MatchString(answer)
+		case MatchContains:
+			ok = strings.Contains(strings.ToLower(answer), strings.ToLower(accept))
+		default:
+			ok = answer == accept
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Riddle looks up a riddle by ID.
+func (b *RiddleBank) Riddle(id string) (Riddle, bool) {
+	i, ok := b.byID[id]
+	if !ok {
+		return Riddle{}, false
+	}
+	return b.Riddles[i], true
+}
+
+// IDs lists the riddle IDs in the order they are offered.
+func (b *RiddleBank) IDs() []string {
+	ids := make([]string, len(b.Riddles))
+	for i, riddle := range b.Riddles {
+		ids[i] = riddle.ID
+	}
+	return ids
+}
+
+// Topics lists the languages and topics in the bank, in the order they
+// first appear.
+func (b *RiddleBank) Topics() []string {
+	var topics []string
+	seen := make(map[string]bool)
+	for _, riddle := range b.Riddles {
+		if !seen[riddle.Language] {
+			seen[riddle.Language] = true
+			topics = append(topics, riddle.Language)
+		}
+	}
+	return topics
+}
+
+// Topic returns the riddles on a topic, easiest first and otherwise in
+// bank order.
+func (b *RiddleBank) Topic(topic string) []Riddle {
+	var riddles []Riddle
+	for _, riddle := range b.Riddles {
+		if riddle.Language == topic {
+			riddles = append(riddles, riddle)
+		}
+	}
+	sort.SliceStable(riddles, func(i, j int) bool {
+		return riddles[i].Difficulty.tier() < riddles[j].Difficulty.tier()
+	})
+	return riddles
+}
+
+// SetRiddleBank swaps in a new riddle bank. Attempts are kept by riddle
+// ID, so riddles that keep their ID keep their attempts.
+func (g *Game) SetRiddleBank(bank *RiddleBank) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.riddles = bank
+}
+
+// RiddleBank returns the game's riddles. The bank must not be changed.
+func (g *Game) RiddleBank() *RiddleBank {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.riddleBank()
+}
+
+// riddleBank returns the game's riddle bank, falling back to the default.
+// The caller must hold g.mu.
+func (g *Game) riddleBank() *RiddleBank {
+	if g.riddles == nil {
+		return defaultRiddles
+	}
+	return g.riddles
+}
@@ -0,0 +1,346 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		match  MatchKind
+		accept []string
+		answer string
+		want   bool
+	}{
+		{MatchExact, []string{":="}, " := ", true},
+		{MatchExact, []string{"yes"}, "Yes", false},
+		{MatchCaseInsensitive, []string{"no", "yes"}, "YES", true},
+		{MatchRegex, []string{`^re-?entrancy$`}, "re-entrancy", true},
+		{MatchRegex, []string{`^re-?entrancy$`}, "a reentrancy", false},
+		{MatchContains, []string{"reentrancy"}, "It's a Reentrancy attack", true},
+		{MatchContains, []string{"reentrancy"}, "overflow", false},
+	} {
+		m := Matcher{Match: tc.match, Accept: tc.accept}
+		if err := m.compile(); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Matches(tc.answer); got != tc.want {
+			t.Errorf("%s %q matches %q = %v, want %v", tc.match, tc.accept, tc.answer, got, tc.want)
+		}
+	}
+}
+
+func TestLoadRiddleBank(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("b.json", `{"riddles": [{"id": "sql", "language": "sql", "prompt": "Fetch everything", "answer": {"match": "regex", "accept": ["(?i)^select \\*"]}, "reward": {"TechXP": 10, "GameTokens": 1}}]}`)
+	write("a.yaml", `
+defaults: {max_attempts: 2, cooldown: 1m, hint_cost: {techtokens: 1}}
+riddles:
+  - id: rust
+    language: rust
+    prompt: Who owns it?
+    answer: {accept: [the borrow checker]}
+    reward: {gamexp: 1, arttokens: 2}
+    max_attempts: 1
+`)
+	write("notes.txt", "not a riddle")
+
+	bank, err := LoadRiddleBank(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := bank.IDs(); len(ids) != 2 || ids[0] != "rust" || ids[1] != "sql" {
+		t.Fatalf("IDs = %q, want files in name order", ids)
+	}
+	rust, _ := bank.Riddle("rust")
+	if rust.Answer.Match != MatchExact || rust.Reward != (Balances{GameXP: 1, ArtTokens: 2}) {
+		t.Errorf("rust riddle = %+v", rust)
+	}
+	if want := (RiddlePolicy{MaxAttempts: 1, Cooldown: time.Minute, HintCost: Balances{TechTokens: 1}}); rust.RiddlePolicy != want {
+		t.Errorf("rust policy = %+v, want the file defaults with max_attempts overridden: %+v", rust.RiddlePolicy, want)
+	}
+	sql, _ := bank.Riddle("sql")
+	if sql.RiddlePolicy != (RiddlePolicy{}) || !sql.Answer.Matches("SELECT * FROM players") || sql.Reward != (Balances{TechXP: 10, GameTokens: 1}) {
+		t.Errorf("sql riddle = %+v", sql)
+	}
+
+	if _, err := LoadRiddleBank(t.TempDir()); !errors.Is(err, ErrInvalidRiddleBank) {
+		t.Errorf("empty directory: err = %v, want ErrInvalidRiddleBank", err)
+	}
+	for name, data := range map[string]string{
+		"duplicate id": `{"riddles": [{"id": "sql", "language": "sql", "prompt": "again", "answer": {"accept": ["x"]}}]}`,
+		"bad id":       `{"riddles": [{"id": "a b", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}}]}`,
+		"bad regex":    `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"match": "regex", "accept": ["("]}}]}`,
+		"bad match":    `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"match": "fuzzy", "accept": ["x"]}}]}`,
+		"no answer":    `{"riddles": [{"id": "x", "language": "x", "prompt": "x"}]}`,
+		"bad penalty":  `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}, "penalty": 150}]}`,
+		"bad cooldown": `{"defaults": {"cooldown": "soon"}, "riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}}]}`,
+		"xp for hints": `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}, "hint_cost": {"gamexp": 1}}]}`,
+		"bad tier":     `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}, "difficulty": "brutal"}]}`,
+		"negative":     `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}, "reward": {"gamexp": -5}}]}`,
+		"go, no tests": `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"match": "go"}}]}`,
+		"bad tests":    `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"match": "go", "tests": "package main"}}]}`,
+		"exact, tests": `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"], "tests": "package riddle"}}]}`,
+		"typo":         `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answr": {"accept": ["x"]}}]}`,
+		"reward typo":  `{"riddles": [{"id": "x", "language": "x", "prompt": "x", "answer": {"accept": ["x"]}, "reward": {"GameXPs": 1}}]}`,
+	} {
+		write("c.json", data)
+		if _, err := LoadRiddleBank(dir); !errors.Is(err, ErrInvalidRiddleBank) {
+			t.Errorf("%s: err = %v, want ErrInvalidRiddleBank", name, err)
+		}
+	}
+}
+
+func TestAnswerRiddleFromBank(t *testing.T) {
+	riddles, err := parseRiddles([]byte(`
+riddles:
+  - id: haiku
+    language: poetry
+    prompt: How many syllables?
+    answer: {accept: ["17"]}
+    reward: {arttokens: 3, artxp: 20}
+    max_attempts: 1
+    correct: Lovely.
+    wrong: Count again, next time.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bank, err := NewRiddleBank(riddles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	g.SetRiddleBank(bank)
+	if _, err := g.AnswerRiddle(tippiWalletAddress, "go", ":="); !errors.Is(err, ErrUnknownRiddle) {
+		t.Errorf("riddle outside the bank: err = %v", err)
+	}
+
+	before, _ := g.Player(tippiWalletAddress)
+	result, err := g.AnswerRiddle(tippiWalletAddress, "haiku", "17")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Correct || result.Message != "Lovely." || result.ID != "haiku" || result.Language != "poetry" {
+		t.Errorf("result = %+v", result)
+	}
+	after, _ := g.Player(tippiWalletAddress)
+	if after.ArtTokens != before.ArtTokens+3 || after.ArtXP != before.ArtXP+20 || after.RiddleScore != before.RiddleScore+1 {
+		t.Errorf("reward not paid: before %+v, after %+v", before.Balances, after.Balances)
+	}
+	if !after.RiddleAttempts["haiku"].Solved || after.Badges["all-riddles"].IsZero() {
+		t.Error("solving the whole bank should unlock all-riddles")
+	}
+
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	result, err = g.AnswerRiddle("0xNew", "haiku", "5-7-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Correct || !result.Locked || result.Message != "Count again, next time." {
+		t.Errorf("wrong answer with max_attempts 1 = %+v", result)
+	}
+	if _, err := g.AnswerRiddle("0xNew", "haiku", "17"); !errors.Is(err, ErrRiddleAttempted) {
+		t.Errorf("second try: err = %v", err)
+	}
+}
+
+func TestNextRiddle(t *testing.T) {
+	g := New()
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	var tiers []Difficulty
+	for {
+		riddle, err := g.NextRiddle("0xNew", "go")
+		if errors.Is(err, ErrTopicExhausted) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tiers = append(tiers, riddle.Difficulty)
+		before, _ := g.Player("0xNew")
+		if err := g.RecordRiddle("0xNew", riddle.ID, true); err != nil {
+			t.Fatal(err)
+		}
+		after, _ := g.Player("0xNew")
+		if gained := after.GameXP - before.GameXP; gained != riddle.Reward.GameXP*len(tiers) {
+			t.Errorf("%s (%s) paid %d Game XP, want %d", riddle.ID, riddle.Difficulty, gained, riddle.Reward.GameXP*len(tiers))
+		}
+	}
+	if !reflect.DeepEqual(tiers, Difficulties) {
+		t.Errorf("go riddles served in tiers %q, want %q", tiers, Difficulties)
+	}
+	if _, err := g.NextRiddle("0xNew", "cobol"); !errors.Is(err, ErrUnknownRiddle) {
+		t.Errorf("unknown topic: err = %v", err)
+	}
+}
+
+func TestRiddleAttempts(t *testing.T) {
+	riddles, err := parseRiddles([]byte(`
+defaults: {max_attempts: 3, penalty: 40, hint_cost: {gametokens: 2}}
+riddles:
+  - id: haiku
+    language: poetry
+    prompt: How many syllables?
+    answer: {accept: ["17"]}
+    reward: {arttokens: 10, artxp: 20}
+    hints: [Count them., "Five, seven, five."]
+  - id: limerick
+    language: poetry
+    prompt: How many lines?
+    answer: {accept: ["5"]}
+    cooldown: 1h
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bank, err := NewRiddleBank(riddles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New()
+	g.SetRiddleBank(bank)
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	start, _ := g.Player("0xNew")
+
+	for i, want := range []string{"Count them.", "Five, seven, five."} {
+		hints, err := g.RiddleHint("0xNew", "haiku")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hints) != i+1 || hints[i] != want {
+			t.Errorf("hint %d = %q, want %q last", i+1, hints, want)
+		}
+	}
+	if _, err := g.RiddleHint("0xNew", "haiku"); !errors.Is(err, ErrNoMoreHints) {
+		t.Errorf("third hint: err = %v", err)
+	}
+	if player, _ := g.Player("0xNew"); player.GameTokens != start.GameTokens-4 {
+		t.Errorf("two hints cost %d Game Tokens, want 4", start.GameTokens-player.GameTokens)
+	}
+
+	result, err := g.AnswerRiddle("0xNew", "haiku", "12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Correct || result.Locked || result.Tries != 1 || result.AttemptsLeft != 2 {
+		t.Errorf("first wrong answer = %+v", result)
+	}
+	before, _ := g.Player("0xNew")
+	if result, err = g.AnswerRiddle("0xNew", "haiku", "17"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Balances{ArtTokens: 6, ArtXP: 12}); !result.Correct || !result.Locked || result.Paid != want {
+		t.Errorf("right answer after one wrong = %+v, want %+v paid", result, want)
+	}
+	after, _ := g.Player("0xNew")
+	if after.ArtXP != before.ArtXP+12 {
+		t.Errorf("paid %d Art XP, want 12", after.ArtXP-before.ArtXP)
+	}
+	if attempt := g.RiddleAttempt("0xNew", "haiku"); attempt.Tries != 2 || !attempt.Solved || attempt.Hints != 2 || attempt.LastTry.IsZero() {
+		t.Errorf("attempt = %+v", attempt)
+	}
+	if _, err := g.AnswerRiddle("0xNew", "haiku", "17"); !errors.Is(err, ErrRiddleAttempted) {
+		t.Errorf("answering a solved riddle: err = %v", err)
+	}
+
+	if _, err := g.AnswerRiddle("0xNew", "limerick", "4"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.AnswerRiddle("0xNew", "limerick", "5"); !errors.Is(err, ErrRiddleCooldown) {
+		t.Errorf("answering during the cooldown: err = %v", err)
+	}
+	if attempt := g.RiddleAttempt("0xNew", "limerick"); attempt.Tries != 1 {
+		t.Errorf("a refused answer was counted: %+v", attempt)
+	}
+}
+
+// TestUnpaidRiddleStaysOpen checks that a solve whose reward can't be
+// stored isn't recorded either, so the player can answer again.
+func TestUnpaidRiddleStaysOpen(t *testing.T) {
+	store := &failingStore{fail: map[string]bool{}}
+	g, err := Open(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.fail["AppendLedger"] = true
+	if _, err := g.AnswerRiddle(tippiWalletAddress, "go", ":="); !errors.Is(err, errStoreDown) {
+		t.Fatalf("answering with the ledger down: err = %v", err)
+	}
+	if attempt := g.RiddleAttempt(tippiWalletAddress, "go"); attempt.Tries != 0 || attempt.Solved {
+		t.Errorf("unpaid solve was recorded: %+v", attempt)
+	}
+
+	delete(store.fail, "AppendLedger")
+	result, err := g.AnswerRiddle(tippiWalletAddress, "go", ":=")
+	if err != nil || !result.Correct || result.Paid == (Balances{}) {
+		t.Errorf("answering again = %+v, %v", result, err)
+	}
+}
+
+func TestRiddleAttemptLegacyJSON(t *testing.T) {
+	var attempts map[string]RiddleAttempt
+	if err := json.Unmarshal([]byte(`{"go": true, "react": false, "solidity": {"Tries": 2, "Hints": 1}}`), &attempts); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]RiddleAttempt{
+		"go":       {Tries: 1, Solved: true},
+		"react":    {Tries: 1},
+		"solidity": {Tries: 2, Hints: 1},
+	}
+	if !reflect.DeepEqual(attempts, want) {
+		t.Errorf("attempts = %+v, want %+v", attempts, want)
+	}
+}
+
+func TestSmallerBankKeepsBoughtHints(t *testing.T) {
+	g := New()
+	for range 2 {
+		if _, err := g.RiddleHint(tippiWalletAddress, "go"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	riddles, err := parseRiddles([]byte(`
+riddles:
+  - id: go
+    language: go
+    prompt: Declare and assign?
+    answer: {accept: [":="]}
+    hints: [Two characters.]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bank, err := NewRiddleBank(riddles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetRiddleBank(bank)
+
+	riddle, err := g.Riddle(tippiWalletAddress, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hints := riddle.RevealedHints(g.RiddleAttempt(tippiWalletAddress, "go")); !reflect.DeepEqual(hints, []string{"Two characters."}) {
+		t.Errorf("revealed hints = %q, want the one the bank has", hints)
+	}
+	if _, err := g.RiddleHint(tippiWalletAddress, "go"); !errors.Is(err, ErrNoMoreHints) {
+		t.Errorf("hint after the bank shrank: err = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Description: This file contains the role model (admin, gamemaster, player, guest) and the permission table. Every restricted command, in the REPL or over HTTP, asks Game.Can before doing anything.
+
+package game
+
+import "errors"
+
+type Role string
+
+const (
+	RoleGuest      Role = "guest"
+	RolePlayer     Role = "player"
+	RoleGamemaster Role = "gamemaster"
+	RoleAdmin      Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermAddPlayer    Permission = "add"
+	PermRemovePlayer Permission = "remove"
+	PermAward        Permission = "award"
+	PermSaveGame     Permission = "save"
+	PermLoadGame     Permission = "load"
+	PermManageRoles  Permission = "roles"
+	PermTrade        Permission = "trade" // Transfer tokens and use the marketplace
+)
+
+// rolePermissions is the single place that decides who may do what.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:      {PermAddPlayer, PermRemovePlayer, PermAward, PermSaveGame, PermLoadGame, PermManageRoles, PermTrade},
+	RoleGamemaster: {PermAddPlayer, PermRemovePlayer, PermAward, PermSaveGame, PermTrade},
+	RolePlayer:     {PermTrade},
+	RoleGuest:      {},
+}
+
+var (
+	ErrUnknownRole      = errors.New("unknown role (options: admin, gamemaster, player, guest)")
+	ErrLastAdmin        = errors.New("cannot remove the last admin")
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// ParseRole turns user input into a Role.
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", ErrUnknownRole
+	}
+	return role, nil
+}
+
+// Can reports whether the role has the permission.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleOf returns the role of the wallet. Unknown wallets are guests, and
+// players from saves that predate roles are plain players.
+func (g *Game) RoleOf(walletAddress string) Role {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.roleOf(walletAddress)
+}
+
+func (g *Game) roleOf(walletAddress string) Role {
+	player, exists := g.Players[walletAddress]
+	if !exists {
+		return RoleGuest
+	}
+	if player.Role == "" {
+		return RolePlayer
+	}
+	return player.Role
+}
+
+// Can reports whether the session's player holds the permission. A nil session is a guest.
+func (g *Game) Can(session *Session, p Permission) bool {
+	if session == nil {
+		return RoleGuest.Can(p)
+	}
+	return g.RoleOf(session.WalletAddress).Can(p)
+}
+
+// SetRole grants a role to a player. Revoking is granting RolePlayer.
+func (g *Game) SetRole(walletAddress string, role Role) error {
+	if _, ok := rolePermissions[role]; !ok {
+		return ErrUnknownRole
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.Players[walletAddress]; !exists {
+		return ErrPlayerNotFound
+	}
+	if g.roleOf(walletAddress) == RoleAdmin && role != RoleAdmin && g.countRole(RoleAdmin) == 1 {
+		return ErrLastAdmin
+	}
+	return g.updatePlayer(walletAddress, func(player *Player) {
+		player.Role = role
+	})
+}
+
+func (g *Game) countRole(role Role) int {
+	n := 0
+	for walletAddress := range g.Players {
+		if g.roleOf(walletAddress) == role {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRoleCan(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleAdmin, PermManageRoles, true},
+		{RoleAdmin, PermLoadGame, true},
+		{RoleGamemaster, PermAddPlayer, true},
+		{RoleGamemaster, PermAward, true},
+		{RoleGamemaster, PermSaveGame, true},
+		{RoleGamemaster, PermLoadGame, false},
+		{RoleGamemaster, PermManageRoles, false},
+		{RolePlayer, PermTrade, true},
+		{RolePlayer, PermAddPlayer, false},
+		{RolePlayer, PermRemovePlayer, false},
+		{RolePlayer, PermSaveGame, false},
+		{RoleGuest, PermTrade, false},
+		{Role("pirate"), PermTrade, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.perm); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+
+	g := New()
+	g.AddPlayer("0xPlain", "Plain")
+	if g.Can(nil, PermTrade) {
+		t.Error("a guest can trade")
+	}
+	if !g.Can(&Session{WalletAddress: "0xPlain"}, PermTrade) || g.Can(&Session{WalletAddress: "0xPlain"}, PermAward) {
+		t.Error("a plain player has the wrong permissions")
+	}
+	if !g.Can(&Session{WalletAddress: tippiWalletAddress}, PermManageRoles) {
+		t.Error("the admin can't manage roles")
+	}
+}
+
+func TestSetRole(t *testing.T) {
+	g := New()
+	g.AddPlayer("0xGM", "Gamemaster")
+	tests := []struct {
+		name   string
+		wallet string
+		role   Role
+		want   error
+		after  Role
+	}{
+		{"promote", "0xGM", RoleGamemaster, nil, RoleGamemaster},
+		{"unknown role", "0xGM", Role("pirate"), ErrUnknownRole, RoleGamemaster},
+		{"unknown player", "0xNobody", RoleAdmin, ErrPlayerNotFound, RoleGuest},
+		{"demote the last admin", tippiWalletAddress, RolePlayer, ErrLastAdmin, RoleAdmin},
+		{"second admin", "0xGM", RoleAdmin, nil, RoleAdmin},
+		{"demote an admin who isn't the last", tippiWalletAddress, RolePlayer, nil, RolePlayer},
+		{"demote the new last admin", "0xGM", RoleGuest, ErrLastAdmin, RoleAdmin},
+	}
+	for _, tt := range tests {
+		if err := g.SetRole(tt.wallet, tt.role); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if role := g.RoleOf(tt.wallet); role != tt.after {
+			t.Errorf("%s: role = %s, want %s", tt.name, role, tt.after)
+		}
+	}
+}
+
+func TestRemoveAdmin(t *testing.T) {
+	g := New()
+	g.AddPlayer("0xGM", "Gamemaster")
+	g.AddPlayer("0xAdmin", "Second Admin")
+	g.SetRole("0xGM", RoleGamemaster)
+	g.SetRole("0xAdmin", RoleAdmin)
+	tests := []struct {
+		name          string
+		actor, wallet string
+		want          error
+	}{
+		{"an admin by a gamemaster", "0xGM", "0xAdmin", ErrPermissionDenied},
+		{"an admin by an admin", tippiWalletAddress, "0xAdmin", nil},
+		{"the last admin by themselves", tippiWalletAddress, tippiWalletAddress, ErrLastAdmin},
+		{"the last admin by a gamemaster", "0xGM", tippiWalletAddress, ErrPermissionDenied},
+		{"a gamemaster by themselves", "0xGM", "0xGM", nil},
+	}
+	for _, tt := range tests {
+		if err := g.RemovePlayer(tt.actor, tt.wallet, "testing"); !errors.Is(err, tt.want) {
+			t.Errorf("removing %s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if _, playing := g.Player(tt.wallet); playing != (tt.want != nil) {
+			t.Errorf("removing %s: still playing = %v", tt.name, playing)
+		}
+	}
+}
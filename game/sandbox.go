@@ -0,0 +1,224 @@
+// Description: This file contains the sandbox for code riddles. A player's Go answer is built with the local Go toolchain, offline, and run against the riddle's hidden tests in a subprocess with a time and memory limit. Answers may only import a short list of standard packages, so they can't reach the file system, the network or other processes.
+
+package game
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// sandboxPackage is the package the answer and the hidden tests share.
+	sandboxPackage      = "riddle"
+	sandboxBuildTimeout = time.Minute
+	defaultRunTimeout   = 10 * time.Second
+	defaultMemoryMB     = 256
+	maxSandboxOutput    = 2000 // Bytes of test output shown to the player
+)
+
+// SandboxImports are the packages a code answer may import.
+var SandboxImports = []string{
+	"bytes", "cmp", "container/heap", "container/list", "errors", "fmt",
+	"maps", "math", "math/bits", "slices", "sort", "strconv", "strings",
+	"unicode", "unicode/utf8",
+}
+
+var ErrNoGoToolchain = errors.New("code riddles need the go command, which isn't installed")
+
+// sandboxSlots limits how many answers build and run at once.
+var sandboxSlots = make(chan struct{}, runtime.NumCPU())
+
+// checkGo builds the answer and runs the matcher's tests against it. A
+// wrong answer, including one that doesn't build, is not an error; the
+// output says what went wrong.
+func (m Matcher) checkGo(answer string) (bool, string, error) {
+	source, problem := sandboxSource(answer)
+	if problem != "" {
+		return false, problem, nil
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		return false, "", ErrNoGoToolchain
+	}
+
+	sandboxSlots <- struct{}{}
+	defer func() { <-sandboxSlots }()
+
+	dir, err := os.MkdirTemp("", "ceptor-riddle-")
+	if err != nil {
+		return false, "", err
+	}
+	defer os.RemoveAll(dir)
+	for name, data := range map[string]string{
+		"go.mod":         "module " + sandboxPackage + "\n\ngo 1.22\n",
+		"answer.go":      source,
+		"riddle_test.go": m.Tests,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			return false, "", err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sandboxBuildTimeout)
+	defer cancel()
+	build := exec.CommandContext(ctx, goCmd, "test", "-c", "-o", "riddle.test", ".")
+	build.Dir = dir
+	build.Env = append(os.Environ(),
+		"GOPROXY=off", // Everything the answer may import is in the standard library
+		"GOTOOLCHAIN=local",
+		"GOWORK=off",
+		"GOFLAGS=",
+		"CGO_ENABLED=0",
+	)
+	output := &tailBuffer{max: 4 * maxSandboxOutput}
+	build.Stdout, build.Stderr = output, output
+	if err := build.Run(); err != nil {
+		if ctx.Err() != nil {
+			return false, "", fmt.Errorf("building the answer took longer than %s", sandboxBuildTimeout)
+		}
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			return false, "", err
+		}
+		return false, buildErrors(output.Bytes(), dir), nil
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+	run := sandboxCommand(ctx, m.Memory, "./riddle.test", "-test.v", "-test.timeout="+m.Timeout.String())
+	run.Dir = dir
+	run.Env = []string{"GOMEMLIMIT=" + strconv.Itoa(m.Memory) + "MiB"}
+	output.Reset()
+	run.Stdout, run.Stderr = output, output
+	err = run.Run()
+	switch {
+	case err == nil:
+		return true, "", nil
+	case ctx.Err() != nil, bytes.Contains(output.Bytes(), []byte("panic: test timed out")):
+		return false, fmt.Sprintf("Your code took longer than %s.", m.Timeout), nil
+	case bytes.Contains(output.Bytes(), []byte("cannot allocate memory")), bytes.Contains(output.Bytes(), []byte("out of memory")):
+		return false, fmt.Sprintf("Your code used more than %d MB of memory.", m.Memory), nil
+	}
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) {
+		return false, "", err
+	}
+	return false, testReport(output.Bytes()), nil
+}
+
+// sandboxSource puts the answer in the sandbox package, adding the
+// package clause if the player left it out, and checks its imports. It
+// returns what is wrong with the answer, if anything.
+func sandboxSource(answer string) (string, string) {
+	fset := token.NewFileSet()
+	if file, err := parser.ParseFile(fset, "", answer, parser.PackageClauseOnly); err != nil {
+		// Keep the player's line numbers in compiler errors.
+		answer = "package " + sandboxPackage + "\n//line answer.go:1\n" + answer
+	} else if file.Name.Name != sandboxPackage {
+		return "", fmt.Sprintf("Use package %s, or leave the package clause out.", sandboxPackage)
+	}
+	// Parse it all, not just the imports: the import check is only as good
+	// as the parse, so an answer that doesn't parse goes no further.
+	file, err := parser.ParseFile(fset, "answer.go", answer, 0)
+	if err != nil {
+		return "", "Your code doesn't build:\n" + err.Error()
+	}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if !slices.Contains(SandboxImports, path) {
+			return "", fmt.Sprintf("Code riddles can't import %q. You may import %s.", path, strings.Join(SandboxImports, ", "))
+		}
+	}
+	return answer, ""
+}
+
+// buildErrors is what the compiler said about the answer. Errors in the
+// hidden tests are left out, since they quote the tests.
+func buildErrors(output []byte, dir string) string {
+	var errs []string
+	for _, line := range strings.Split(trimOutput(output, dir), "\n") {
+		if line = strings.TrimPrefix(line, "./"); strings.HasPrefix(line, "answer.go:") {
+			errs = append(errs, line)
+		}
+	}
+	if len(errs) == 0 {
+		return "Your code doesn't build with the tests. Check the function's name and signature against the prompt."
+	}
+	return "Your code doesn't build:\n" + strings.Join(errs, "\n")
+}
+
+// testResult matches the verbose test output's "--- FAIL: TestTally/empty".
+var testResult = regexp.MustCompile(`(?m)^\s*--- (PASS|FAIL): (\S+) \(`)
+
+// testReport says which tests passed and which failed, without what they
+// expected, so the test cases stay hidden.
+func testReport(output []byte) string {
+	var passed, failed []string
+	for _, match := range testResult.FindAllSubmatch(output, -1) {
+		if string(match[1]) == "PASS" {
+			passed = append(passed, string(match[2]))
+		} else {
+			failed = append(failed, string(match[2]))
+		}
+	}
+	var report []string
+	if bytes.Contains(output, []byte("\npanic: ")) || bytes.HasPrefix(output, []byte("panic: ")) {
+		report = append(report, "Your code panicked.")
+	}
+	if len(failed) > 0 {
+		report = append(report, "Failed: "+strings.Join(failed, ", "))
+	}
+	if len(passed) > 0 {
+		report = append(report, "Passed: "+strings.Join(passed, ", "))
+	}
+	if len(report) == 0 {
+		return "Your code didn't pass the tests."
+	}
+	return strings.Join(report, "\n")
+}
+
+// tailBuffer keeps the last max bytes written to it, or a little more, and
+// throws the rest away, so an answer that prints without end can't fill
+// the server's memory. The end is what says why a build or test failed.
+type tailBuffer struct {
+	max  int
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p[max(0, len(p)-b.max):]...)
+	if len(b.data) > 2*b.max {
+		b.data = append(b.data[:0], b.data[len(b.data)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	return b.data[max(0, len(b.data)-b.max):]
+}
+
+func (b *tailBuffer) Reset() { b.data = b.data[:0] }
+
+// trimOutput makes test output fit to show: no sandbox paths, and only
+// the end of it when it is long.
+func trimOutput(output []byte, dir string) string {
+	text := strings.ReplaceAll(string(output), dir+string(filepath.Separator), "")
+	text = strings.TrimSpace(text)
+	if len(text) > maxSandboxOutput {
+		text = "..." + text[len(text)-maxSandboxOutput:]
+	}
+	return text
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package game
+
+import (
+	"context"
+	"os/exec"
+)
+
+// sandboxCommand runs the program with nothing but the timeout to stop
+// it. Without rlimits, memory is only held back by GOMEMLIMIT.
+func sandboxCommand(ctx context.Context, memoryMB int, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
@@ -0,0 +1,137 @@
+package game
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCodeRiddle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs Go code")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command")
+	}
+	riddle, _ := DefaultRiddleBank().Riddle("go-tally")
+	for _, tc := range []struct {
+		name   string
+		answer string
+		limit  func(m *Matcher)
+		output string // Part of what a wrong answer is told
+	}{
+		{name: "right", answer: `
+import "maps"
+
+func Tally(votes []string) map[string]int {
+	tally := make(map[string]int)
+	for _, vote := range votes {
+		tally[vote]++
+	}
+	return maps.Clone(tally)
+}`},
+		{name: "package clause", answer: "package riddle\n\nfunc Tally(votes []string) map[string]int {\n\ttally := map[string]int{}\n\tfor _, v := range votes {\n\t\ttally[v]++\n\t}\n\treturn tally\n}\n"},
+		{name: "nil map", answer: `
+func Tally(votes []string) map[string]int {
+	var tally map[string]int
+	for _, vote := range votes {
+		if tally == nil {
+			tally = map[string]int{}
+		}
+		tally[vote]++
+	}
+	return tally
+}`, output: "Failed: TestTally, TestTally/no_votes\nPassed: TestTally/two_teams, TestTally/one_vote"},
+		{name: "panics", answer: `
+func Tally(votes []string) map[string]int {
+	panic(votes[0])
+}`, output: "Your code panicked."},
+		{name: "wrong signature", answer: "func Tally(votes string) map[string]int {\n\treturn nil\n}", output: "Check the function's name and signature"},
+		{name: "doesn't build", answer: "func Tally(votes []string) map[string]int {\n\treturn votes\n}", output: "answer.go:2"},
+		{name: "forbidden import", answer: "import \"os\"\n\nfunc Tally([]string) map[string]int { os.Exit(0); return nil }", output: `can't import "os"`},
+		{name: "wrong package", answer: "package main\n\nfunc Tally([]string) map[string]int { return nil }", output: "Use package riddle"},
+		{name: "endless", answer: "func Tally([]string) map[string]int { for {} }",
+			limit: func(m *Matcher) { m.Timeout = 2 * time.Second }, output: "took longer than 2s"},
+		{name: "chatty", answer: "import \"fmt\"\n\nfunc Tally([]string) map[string]int {\n\tfor {\n\t\tfmt.Print(\"Dog Dog Dog \")\n\t}\n}",
+			limit: func(m *Matcher) { m.Timeout = 2 * time.Second }, output: "took longer than 2s"},
+		{name: "greedy", answer: "func Tally([]string) map[string]int {\n\tb := make([]byte, 1<<30)\n\tfor i := range b {\n\t\tb[i] = 1\n\t}\n\treturn map[string]int{string(b[:1]): len(b)}\n}",
+			limit: func(m *Matcher) { m.Memory = 64 }, output: "more than 64 MB"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.name == "greedy" && runtime.GOOS == "windows" {
+				t.Skip("no memory limit without rlimits")
+			}
+			matcher := riddle.Answer
+			if tc.limit != nil {
+				tc.limit(&matcher)
+			}
+			correct, output, err := matcher.Check(tc.answer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tc.output == ""; correct != want || !strings.Contains(output, tc.output) {
+				t.Errorf("correct = %v, want %v; output:\n%s", correct, want, output)
+			}
+			for _, secret := range []string{"want", "Dog", "riddle_test.go"} {
+				if strings.Contains(output, secret) {
+					t.Errorf("output gives away the tests with %q:\n%s", secret, output)
+				}
+			}
+		})
+	}
+}
+
+func TestAnswerCodeRiddle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs Go code")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command")
+	}
+	g := New()
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := g.Player("0xNew")
+	result, err := g.AnswerRiddle("0xNew", "go-tally", "func Tally(votes []string) map[string]int {\n\tt := map[string]int{}\n\tfor _, v := range votes {\n\t\tt[v]++\n\t}\n\treturn t\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := g.Player("0xNew")
+	if !result.Correct || after.TechXP != before.TechXP+20 || after.RiddleScore != before.RiddleScore+1 {
+		t.Errorf("result %+v; Tech XP %d -> %d, riddle score %d -> %d", result, before.TechXP, after.TechXP, before.RiddleScore, after.RiddleScore)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 10}
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(b, "line %d\n", i)
+	}
+	if got := string(b.Bytes()); got != "\nline 999\n" {
+		t.Errorf("kept %q, want the last 10 bytes", got)
+	}
+	if len(b.data) > 2*b.max {
+		t.Errorf("holding %d bytes, want at most %d", len(b.data), 2*b.max)
+	}
+	b.Write([]byte(strings.Repeat("x", 100)))
+	if got := string(b.Bytes()); got != strings.Repeat("x", 10) {
+		t.Errorf("after a long write kept %q", got)
+	}
+}
+
+func TestSandboxSourceRejectsUnparsable(t *testing.T) {
+	for _, answer := range []string{
+		"func Tally(votes []string) map[string]int {\n\treturn nil\n",
+		"import \"os\" \"net\"\n\nfunc Tally([]string) map[string]int { return nil }",
+		"import (\n\t\"strings\"\n\nfunc Tally([]string) map[string]int { return nil }",
+	} {
+		source, problem := sandboxSource(answer)
+		if source != "" || !strings.HasPrefix(problem, "Your code doesn't build:\nanswer.go:") {
+			t.Errorf("%q: source %q, problem %q", answer, source, problem)
+		}
+	}
+}
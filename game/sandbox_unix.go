@@ -0,0 +1,26 @@
+//go:build unix
+
+package game
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// sandboxCommand runs the program in its own process group, so a timeout
+// kills anything it started too, with its data segment capped at memoryMB.
+// The cap is on data rather than address space, since the Go runtime
+// reserves far more address space than it uses.
+func sandboxCommand(ctx context.Context, memoryMB int, name string, args ...string) *exec.Cmd {
+	limit := "ulimit -d " + strconv.Itoa(memoryMB*1024) + ` && exec "$@"`
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", limit, "sh", name}, args...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
@@ -0,0 +1,274 @@
+// Description: This file contains the versioned save file format and the migrations that upgrade older saves, such as disco, to it.
+
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SaveVersion is the save file format written by EncodeSave. Bump it and
+// append to saveMigrations whenever the saved data changes shape.
+const SaveVersion = 6
+
+// SaveFile is the envelope around a saved game. Saves from before the
+// envelope existed (version 0) are a bare Snapshot.
+type SaveFile struct {
+	Version int
+	SavedAt time.Time
+	Game    *Snapshot
+}
+
+var ErrSaveTooNew = errors.New("save file was written by a newer version of the game")
+
+// saveMigrations[v] upgrades the game data of a version v save to version
+// v+1. They work on the decoded JSON so they can see missing fields.
+var saveMigrations = []func(data map[string]interface{}) error{
+	migrateSaveV0,
+	migrateSaveV1,
+	migrateSaveV2,
+	migrateSaveV3,
+	migrateSaveV4,
+	migrateSaveV5,
+}
+
+// migrateSaveV0 upgrades the bare saves written before the envelope: it
+// drops CurrentUser, fills in the riddle fields and roles that older
+// players lack, and keeps Tippi an admin.
+func migrateSaveV0(data map[string]interface{}) error {
+	delete(data, "CurrentUser")
+	for _, key := range []string{"Players", "Purgatory"} {
+		players, _ := data[key].(map[string]interface{})
+		for walletAddress, value := range players {
+			player, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s[%q] is not a player", key, walletAddress)
+			}
+			if attempts, _ := player["RiddleAttempts"].(map[string]interface{}); attempts == nil {
+				player["RiddleAttempts"] = map[string]interface{}{}
+			}
+			if _, exists := player["RiddleScore"]; !exists {
+				player["RiddleScore"] = 0
+			}
+			if role, _ := player["Role"].(string); role == "" {
+				if walletAddress == tippiWalletAddress {
+					player["Role"] = string(RoleAdmin)
+				} else {
+					player["Role"] = string(RolePlayer)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// balanceFields are the Balances fields as they appear in saved JSON.
+var balanceFields = []string{"GameTokens", "ArtTokens", "TechTokens", "ArtXP", "GameXP", "TechXP"}
+
+// migrateSaveV1 turns the award list into the ledger. Balances that no
+// award explains (everything, in saves from before awards were recorded)
+// become an undated "opening balance" entry ahead of the awards, so
+// replaying the ledger gives every player the balances they were saved with.
+func migrateSaveV1(data map[string]interface{}) error {
+	awards, _ := data["Awards"].([]interface{})
+	delete(data, "Awards")
+
+	awarded := make(map[string]map[string]float64)
+	for _, value := range awards {
+		award, ok := value.(map[string]interface{})
+		if !ok {
+			return errors.New("award is not an object")
+		}
+		walletAddress, _ := award["WalletAddress"].(string)
+		if awarded[walletAddress] == nil {
+			awarded[walletAddress] = make(map[string]float64)
+		}
+		for _, field := range balanceFields {
+			amount, _ := award[field].(float64)
+			awarded[walletAddress][field] += amount
+		}
+		award["Actor"] = ""
+		award["Reason"] = ""
+	}
+
+	var ledger []interface{}
+	for _, key := range []string{"Players", "Purgatory"} {
+		players, _ := data[key].(map[string]interface{})
+		for _, walletAddress := range sortedKeys(players) {
+			player, _ := players[walletAddress].(map[string]interface{})
+			entry := map[string]interface{}{
+				"Actor":         SystemActor,
+				"WalletAddress": walletAddress,
+				"Reason":        "opening balance",
+				"Time":          time.Time{},
+			}
+			opening := false
+			for _, field := range balanceFields {
+				amount, _ := player[field].(float64)
+				delta := amount - awarded[walletAddress][field]
+				entry[field] = delta
+				opening = opening || delta != 0
+			}
+			if opening {
+				ledger = append(ledger, entry)
+			}
+		}
+	}
+	data["Ledger"] = append(ledger, awards...)
+	return nil
+}
+
+// migrateSaveV2 adds the (empty) marketplace. Escrowed tokens only exist
+// on offers, so older builds must not read saves that have them.
+func migrateSaveV2(data map[string]interface{}) error {
+	data["Offers"] = map[string]interface{}{}
+	return nil
+}
+
+// migrateSaveV3 gives every player an empty set of visited locations and
+// badges, for the achievement engine.
+func migrateSaveV3(data map[string]interface{}) error {
+	for _, key := range []string{"Players", "Purgatory"} {
+		players, _ := data[key].(map[string]interface{})
+		for _, value := range players {
+			if player, ok := value.(map[string]interface{}); ok {
+				player["Visited"] = map[string]interface{}{}
+				player["Badges"] = map[string]interface{}{}
+			}
+		}
+	}
+	return nil
+}
+
+// migrateSaveV4 gives every player in Purgatory an undated removal note,
+// since older saves never recorded why or when players were removed.
+func migrateSaveV4(data map[string]interface{}) error {
+	players, _ := data["Purgatory"].(map[string]interface{})
+	for _, value := range players {
+		if player, ok := value.(map[string]interface{}); ok {
+			player["Removal"] = map[string]interface{}{}
+		}
+	}
+	return nil
+}
+
+// migrateSaveV5 turns each riddle attempt from a bare solved flag into
+// an attempt record. Older builds allowed one try per riddle, so every
+// recorded attempt counts as one untimed try.
+func migrateSaveV5(data map[string]interface{}) error {
+	for _, key := range []string{"Players", "Purgatory"} {
+		players, _ := data[key].(map[string]interface{})
+		for walletAddress, value := range players {
+			player, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			attempts, _ := player["RiddleAttempts"].(map[string]interface{})
+			for id, attempt := range attempts {
+				solved, ok := attempt.(bool)
+				if !ok {
+					return fmt.Errorf("%s[%q] riddle attempt %q is not true or false", key, walletAddress, id)
+				}
+				attempts[id] = map[string]interface{}{"Tries": 1, "Solved": solved}
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EncodeSave wraps the snapshot in a current-version envelope.
+func EncodeSave(snapshot *Snapshot, savedAt time.Time) ([]byte, error) {
+	return json.MarshalIndent(SaveFile{Version: SaveVersion, SavedAt: savedAt.UTC(), Game: snapshot}, "", "  ")
+}
+
+// DecodeSave reads a save file of any version, running the migrations
+// needed to bring it up to SaveVersion.
+func DecodeSave(data []byte) (*Snapshot, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version := 0
+	gameData := doc
+	if rawVersion, exists := doc["Version"]; exists {
+		number, ok := rawVersion.(float64)
+		if !ok {
+			return nil, fmt.Errorf("save file version %v is not a number", rawVersion)
+		}
+		version = int(number)
+		if gameData, ok = doc["Game"].(map[string]interface{}); !ok {
+			return nil, errors.New("save file has no game data")
+		}
+	}
+	if version > SaveVersion {
+		return nil, fmt.Errorf("%w (version %d, this build reads up to %d)", ErrSaveTooNew, version, SaveVersion)
+	}
+	for ; version < SaveVersion; version++ {
+		if err := saveMigrations[version](gameData); err != nil {
+			return nil, fmt.Errorf("upgrading save from version %d: %w", version, err)
+		}
+	}
+
+	upgraded, err := json.Marshal(gameData)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := NewSnapshot()
+	if err := json.Unmarshal(upgraded, snapshot); err != nil {
+		return nil, err
+	}
+	snapshot.fillDefaults()
+	return snapshot, nil
+}
+
+// fillDefaults replaces nil maps, so a hand-edited save with "null" in it
+// can't make the game panic later.
+func (s *Snapshot) fillDefaults() {
+	if s.Players == nil {
+		s.Players = make(map[string]*Player)
+	}
+	if s.AllowList == nil {
+		s.AllowList = make(map[string]bool)
+	}
+	if s.Purgatory == nil {
+		s.Purgatory = make(map[string]*Player)
+	}
+	if s.Offers == nil {
+		s.Offers = make(map[int]*Offer)
+	}
+	for _, players := range []map[string]*Player{s.Players, s.Purgatory} {
+		for walletAddress, player := range players {
+			if player == nil {
+				delete(players, walletAddress)
+				continue
+			}
+			if player.RiddleAttempts == nil {
+				player.RiddleAttempts = make(map[string]RiddleAttempt)
+			}
+			if player.Visited == nil {
+				player.Visited = make(map[string]bool)
+			}
+			if player.Badges == nil {
+				player.Badges = make(map[string]time.Time)
+			}
+		}
+	}
+	for _, player := range s.Purgatory {
+		if player.Removal == nil {
+			player.Removal = &Removal{}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package game
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenTime is the SavedAt written into golden files, so they don't change on every run.
+var goldenTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestUpgradeDisco checks that the legacy disco save (testdata/disco, a
+// frozen copy) upgrades to exactly testdata/disco.golden.json.
+func TestUpgradeDisco(t *testing.T) {
+	legacy, err := os.ReadFile(filepath.Join("testdata", "disco"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := DecodeSave(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := EncodeSave(snapshot, goldenTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "disco.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, append(got, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(got, '\n'), want) {
+		t.Errorf("upgraded disco does not match %s (rerun with -update if the change is intended):\n%s", golden, got)
+	}
+
+	// The current format must decode to the same game.
+	again, err := DecodeSave(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, snapshot) {
+		t.Errorf("decoding the golden file gave %+v, want %+v", again, snapshot)
+	}
+}
+
+func TestLoadLegacySaveAllowsRiddles(t *testing.T) {
+	g, err := Load(filepath.Join("testdata", "disco"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, walletAddress := range []string{"0xDisco", "0xTippi"} {
+		if g.Players[walletAddress].RiddleAttempts == nil {
+			t.Errorf("%s has nil RiddleAttempts", walletAddress)
+		}
+	}
+	if role := g.RoleOf("0xTippi"); role != RoleAdmin {
+		t.Errorf("0xTippi role = %q, want admin", role)
+	}
+	if role := g.RoleOf("0xDisco"); role != RolePlayer {
+		t.Errorf("0xDisco role = %q, want player", role)
+	}
+	if _, err := g.AnswerRiddle("0xDisco", "go", ":="); err != nil {
+		t.Fatal(err)
+	}
+	if !g.HasAttemptedRiddle("0xDisco", "go") {
+		t.Error("riddle attempt was not recorded")
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	g := New()
+	if err := g.AddPlayer("0xNew", "Newcomer"); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "save.json")
+	if err := g.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Players, g.Players) || !reflect.DeepEqual(loaded.AllowList, g.AllowList) {
+		t.Errorf("loaded game differs from the saved one")
+	}
+}
+
+func TestDecodeSaveRejectsNewerVersion(t *testing.T) {
+	_, err := DecodeSave([]byte(`{"Version": 99, "Game": {}}`))
+	if !errors.Is(err, ErrSaveTooNew) {
+		t.Errorf("err = %v, want ErrSaveTooNew", err)
+	}
+}
+
+func TestUpgradeRiddleAttempts(t *testing.T) {
+	snapshot, err := DecodeSave([]byte(`{"Version": 5, "Game": {"Players": {"0xA": {"WalletAddress": "0xA", "RiddleAttempts": {"go": true, "react": false}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]RiddleAttempt{"go": {Tries: 1, Solved: true}, "react": {Tries: 1}}
+	if got := snapshot.Players["0xA"].RiddleAttempts; !reflect.DeepEqual(got, want) {
+		t.Errorf("RiddleAttempts = %+v, want %+v", got, want)
+	}
+}
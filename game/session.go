@@ -0,0 +1,99 @@
+// Description: This file contains the Session and SessionStore types, which track who is logged in. Every caller (an HTTP client or the local REPL) gets its own session instead of sharing one global current user.
+
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// sessionLifetime is how long a login lasts before the caller must sign in again.
+const sessionLifetime = 24 * time.Hour
+
+// Session ties a caller to the wallet address they logged in with.
+type Session struct {
+	Token         string
+	WalletAddress string
+	CreatedAt     time.Time
+}
+
+// SessionStore holds the active sessions, keyed by token.
+type SessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewSessionStore initializes an empty session store.
+func NewSessionStore() *SessionStore {
+	return &SessionStore{sessions: make(map[string]*Session)}
+}
+
+// Create starts a new session for the wallet address and returns it.
+// Expired sessions are dropped on the way.
+func (s *SessionStore) Create(walletAddress string) (*Session, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{
+		Token:         token,
+		WalletAddress: walletAddress,
+		CreatedAt:     time.Now(),
+	}
+	s.mu.Lock()
+	for key, old := range s.sessions {
+		if old.expired(session.CreatedAt) {
+			delete(s.sessions, key)
+		}
+	}
+	s.sessions[token] = session
+	s.mu.Unlock()
+	return session, nil
+}
+
+// Get looks up a session by its token. Expired sessions are not found.
+func (s *SessionStore) Get(token string) (*Session, bool) {
+	s.mu.RLock()
+	session, ok := s.sessions[token]
+	s.mu.RUnlock()
+	if ok && session.expired(time.Now()) {
+		s.Delete(token)
+		return nil, false
+	}
+	return session, ok
+}
+
+// Delete ends the session with the given token.
+func (s *SessionStore) Delete(token string) {
+	s.mu.Lock()
+	delete(s.sessions, token)
+	s.mu.Unlock()
+}
+
+// DeleteWallet ends every session the wallet address has, such as when the
+// player is removed.
+func (s *SessionStore) DeleteWallet(walletAddress string) {
+	s.mu.Lock()
+	for token, session := range s.sessions {
+		if session.WalletAddress == walletAddress {
+			delete(s.sessions, token)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// expired reports whether the session has outlived sessionLifetime at now.
+func (session *Session) expired(now time.Time) bool {
+	return now.Sub(session.CreatedAt) > sessionLifetime
+}
+
+// newSessionToken returns a random, hex-encoded session token.
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,60 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionExpires(t *testing.T) {
+	sessions := NewSessionStore()
+	session, err := sessions.Create("0xTippi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sessions.Get(session.Token); !ok {
+		t.Fatal("new session not found")
+	}
+	session.CreatedAt = time.Now().Add(-sessionLifetime - time.Second)
+	if _, ok := sessions.Get(session.Token); ok {
+		t.Error("expired session was found")
+	}
+	if _, ok := sessions.sessions[session.Token]; ok {
+		t.Error("expired session was kept")
+	}
+
+	stale, err := sessions.Create("0xTippi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.CreatedAt = time.Now().Add(-sessionLifetime - time.Second)
+	if _, err := sessions.Create("0xTippi"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sessions.sessions[stale.Token]; ok || len(sessions.sessions) != 1 {
+		t.Errorf("expired session was not swept: %d sessions", len(sessions.sessions))
+	}
+}
+
+func TestRemovePlayerEndsSessions(t *testing.T) {
+	g := New()
+	sessions := NewSessionStore()
+	g.SetSessionStore(sessions)
+	g.AddPlayer("0xGone", "Gone")
+	gone, err := sessions.Create("0xGone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tippi, err := sessions.Create("0xTippi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePlayer("0xTippi", "0xGone", "left the club"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sessions.Get(gone.Token); ok {
+		t.Error("removed player is still logged in")
+	}
+	if _, ok := sessions.Get(tippi.Token); !ok {
+		t.Error("removing a player logged someone else out")
+	}
+}
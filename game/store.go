@@ -0,0 +1,57 @@
+// Description: This file contains the Store interface that persists the game. Game writes through to its store on every change, so nothing is lost when nobody runs "save".
+
+package game
+
+// Store persists players, the allow list, Purgatory, the ledger and the
+// marketplace.
+// Implementations live in the store package.
+type Store interface {
+	// Load returns everything in the store, for Open to start from.
+	Load() (*Snapshot, error)
+	// GetPlayer returns ErrPlayerNotFound for unknown wallets.
+	GetPlayer(walletAddress string) (*Player, error)
+	PutPlayer(player *Player) error
+	DeletePlayer(walletAddress string) error
+	SetAllowed(walletAddress string, allowed bool) error
+	PutPurgatory(player *Player) error
+	DeletePurgatory(walletAddress string) error
+	// AppendLedger adds the entries to the end of the ledger in one
+	// transaction: either all of them are stored or none are.
+	AppendLedger(entries ...LedgerEntry) error
+	// PutOffer stores the offer together with the ledger entries that move
+	// its tokens, in one transaction.
+	PutOffer(offer *Offer, entries ...LedgerEntry) error
+	// Replace throws away the stored state and writes the snapshot instead.
+	Replace(snapshot *Snapshot) error
+	Close() error
+}
+
+// pathStore is a Store kept in a file, which Save must not write over.
+type pathStore interface {
+	Path() string
+}
+
+// Snapshot is the whole persisted game, and the game data inside a SaveFile.
+type Snapshot struct {
+	Players   map[string]*Player // Keyed by wallet address
+	AllowList map[string]bool    // Keyed by wallet address
+	Purgatory map[string]*Player // Keyed by wallet address
+	Ledger    []LedgerEntry      // Oldest first
+	Offers    map[int]*Offer     // Keyed by offer ID
+}
+
+// NewSnapshot returns an empty snapshot with its maps initialized.
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		Players:   make(map[string]*Player),
+		AllowList: make(map[string]bool),
+		Purgatory: make(map[string]*Player),
+		Offers:    make(map[int]*Offer),
+	}
+}
+
+// Clone returns a deep copy of the player, so a store never shares memory with a Game.
+func (p *Player) Clone() *Player {
+	c := p.clone()
+	return &c
+}
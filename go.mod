@@ -0,0 +1,13 @@
+module github.com/tippi-fifestarr/go-ceptor
+
+go 1.22
+
+require (
+	github.com/chzyer/readline v1.5.1
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0
+	github.com/mattn/go-sqlite3 v1.14.33
+	golang.org/x/crypto v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.28.0 // indirect
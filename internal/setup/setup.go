@@ -0,0 +1,83 @@
+// Package setup holds the start-up both commands share: the flags that say
+// where the game is kept, which levels and riddles it plays with and when
+// Purgatory is purged, and opening the game they describe.
+package setup
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+	"github.com/tippi-fifestarr/go-ceptor/store"
+)
+
+// Flags are the shared command-line flags.
+type Flags struct {
+	Store      string
+	Levels     string
+	Riddles    string
+	PurgeAfter int // Days; 0 keeps players in Purgatory for good
+}
+
+// Register defines the shared flags on fs.
+func Register(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.Store, "store", "", `where to keep the game: a JSON save file such as "disco", or "sqlite:ceptor.db" (default: memory only)`)
+	fs.StringVar(&f.Levels, "levels", "", "JSON file with the level curves and level-up rewards (default: the built-in levels.json)")
+	fs.StringVar(&f.Riddles, "riddles", "", "YAML or JSON riddle bank, or a directory of them (default: the built-in riddles.yaml)")
+	fs.IntVar(&f.PurgeAfter, "purge-after", 0, "delete players for good once they have been in Purgatory this many days (default: keep them)")
+	return f
+}
+
+// Open starts the game from the store, or in memory when there is none,
+// with the levels and riddles the flags name.
+func (f *Flags) Open() (*game.Game, error) {
+	g := game.New()
+	if f.Store != "" {
+		s, err := store.Open(f.Store)
+		if err != nil {
+			return nil, err
+		}
+		if g, err = game.Open(s); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	if f.Levels != "" {
+		levels, err := game.LoadLevelConfig(f.Levels)
+		if err != nil {
+			g.Close()
+			return nil, err
+		}
+		g.SetLevelConfig(levels)
+	}
+	if f.Riddles != "" {
+		riddles, err := game.LoadRiddleBank(f.Riddles)
+		if err != nil {
+			g.Close()
+			return nil, err
+		}
+		g.SetRiddleBank(riddles)
+	}
+	return g, nil
+}
+
+// AutoPurge purges Purgatory in the background until ctx is done, if
+// -purge-after was given, logging whoever is purged.
+func (f *Flags) AutoPurge(ctx context.Context, g *game.Game) {
+	if f.PurgeAfter > 0 {
+		go g.AutoPurge(ctx, time.Duration(f.PurgeAfter)*24*time.Hour, time.Hour, logPurge)
+	}
+}
+
+func logPurge(purged []string, err error) {
+	if len(purged) > 0 {
+		log.Println("Purged from Purgatory:", strings.Join(purged, ", "))
+	}
+	if err != nil {
+		log.Println("Purge error:", err)
+	}
+}
@@ -0,0 +1,164 @@
+// Description: This file contains the batch runner. RunScript feeds a script of REPL commands through the same command table as the prompt, stops at the first failure, and in a dry run reports what the script would have changed.
+
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// ErrCommandFailed is returned by RunScript when a command in the script
+// fails. Other errors mean the script never got going.
+var ErrCommandFailed = errors.New("command failed")
+
+// BatchOptions tune RunScript.
+type BatchOptions struct {
+	// As runs the script logged in as this wallet, without a signature.
+	// It must belong to a player who may log in. Empty runs as a guest
+	// until the script logs in itself.
+	As string
+	// DryRun runs the script against a copy of the game, skips commands
+	// that read or write files, and reports what would have changed.
+	DryRun bool
+}
+
+// RunScript runs the commands in script one line at a time, like the
+// prompt would, and stops at the first one that fails, like "set -e".
+// Blank lines and lines starting with # are skipped. A line a command asks
+// for, such as a login signature or a riddle answer, is read from the line
+// after it. name is used in messages, e.g. "setup.txt:3: command failed".
+func RunScript(g *game.Game, challenges *game.ChallengeStore, script io.Reader, name string, opts BatchOptions) error {
+	target := g
+	if opts.DryRun {
+		var err error
+		if target, err = g.Fork(); err != nil {
+			return err
+		}
+	}
+	before, err := target.Fork()
+	if err != nil {
+		return err
+	}
+	in := &plainReader{buf: bufio.NewReader(script), quiet: true}
+	s := &shell{g: target, challenges: challenges, in: in, color: newPalette(), dryRun: opts.DryRun}
+	if opts.As != "" {
+		if !target.Login(opts.As) {
+			return fmt.Errorf("%s may not log in", opts.As)
+		}
+		s.session = &game.Session{WalletAddress: opts.As}
+	}
+
+	var failure error
+	for !s.done {
+		printNotifications(target, s.session)
+		input, err := in.ReadLine("")
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line := in.lines
+		words, err := tokenize(input)
+		if err != nil {
+			failure = fmt.Errorf("%s:%d: %w: %v", name, line, ErrCommandFailed, err)
+			break
+		}
+		if len(words) == 0 || strings.HasPrefix(words[0], "#") {
+			continue
+		}
+		fmt.Println(s.color.dim("> " + strings.TrimSpace(input)))
+		s.execute(words)
+		if s.failed {
+			failure = fmt.Errorf("%s:%d: %w: %s", name, line, ErrCommandFailed, strings.TrimSpace(input))
+			break
+		}
+	}
+	if opts.DryRun {
+		fmt.Println()
+		printChanges(before, target)
+	}
+	return failure
+}
+
+// printChanges reports what the script changed between before and after,
+// two games that nothing else is using.
+func printChanges(before, after *game.Game) {
+	fmt.Println("Dry run, nothing was changed. The script would have:")
+	changed := false
+	report := func(format string, a ...interface{}) {
+		fmt.Printf("  "+format+"\n", a...)
+		changed = true
+	}
+
+	for _, walletAddress := range sortedKeys(after.Players) {
+		player := after.Players[walletAddress]
+		old, existed := before.Players[walletAddress]
+		switch {
+		case !existed && before.Purgatory[walletAddress] != nil:
+			report("restored %s (%s) from Purgatory", walletAddress, player.PlayerName)
+		case !existed:
+			report("added %s (%s)", walletAddress, player.PlayerName)
+		case before.RoleOf(walletAddress) != after.RoleOf(walletAddress):
+			report("made %s a %s (was %s)", walletAddress, after.RoleOf(walletAddress), before.RoleOf(walletAddress))
+		case old.PlayerName != player.PlayerName:
+			report("renamed %s to %s", walletAddress, player.PlayerName)
+		}
+	}
+	for _, walletAddress := range sortedKeys(after.Purgatory) {
+		if _, existed := before.Purgatory[walletAddress]; existed {
+			continue
+		}
+		player := after.Purgatory[walletAddress]
+		if _, existed := before.Players[walletAddress]; existed {
+			report("moved %s (%s) to Purgatory", walletAddress, player.PlayerName)
+		} else {
+			report("added %s (%s) and moved them to Purgatory", walletAddress, player.PlayerName)
+		}
+	}
+	for _, walletAddress := range sortedKeys(before.Purgatory) {
+		_, restored := after.Players[walletAddress]
+		if _, exists := after.Purgatory[walletAddress]; !exists && !restored {
+			report("purged %s", walletAddress)
+		}
+	}
+
+	for _, entry := range after.Ledger[len(before.Ledger):] {
+		report("recorded for %s: %s", entry.WalletAddress, describeLedgerEntry(entry))
+	}
+
+	var ids []int
+	for id := range after.Offers {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		offer := after.Offers[id]
+		old, existed := before.Offers[id]
+		switch {
+		case !existed:
+			report("posted offer #%d: %d %s for %d %s", id, offer.GiveAmount, offer.Give, offer.WantAmount, offer.Want)
+		case old.Status != offer.Status:
+			report("marked offer #%d %s", id, offer.Status)
+		}
+	}
+
+	if !changed {
+		fmt.Println("  changed nothing")
+	}
+}
+
+func sortedKeys(players map[string]*game.Player) []string {
+	keys := make([]string, 0, len(players))
+	for key := range players {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
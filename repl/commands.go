@@ -0,0 +1,839 @@
+// Description: This file contains the REPL's command table. Every command names its arguments, the permission it needs and whether it needs a login, and help, usage, argument checking and completion are all driven from the table.
+
+package repl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tippi-fifestarr/go-ceptor/content"
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// command is one entry in the command registry. Everything the prompt knows
+// about a command, from dispatch to permission checks to help, comes from here.
+type command struct {
+	name    string
+	aliases []string
+	// args is the argument schema, which doubles as the usage line: <x> is
+	// required, [x] optional, a trailing ... takes the rest of the line,
+	// and a bare word must be typed as is.
+	args  string
+	perm  game.Permission // Needed to run the command; empty means anyone
+	login bool            // Needs a logged in player even without a permission
+	// external commands read or write files outside the game, so a dry
+	// run skips them.
+	external bool
+	format   bool // Takes --format, which the command finds in s.format
+	help     string
+	run      func(s *shell, args []string)
+
+	params []param // Parsed from args
+}
+
+type param struct {
+	name     string
+	optional bool
+	rest     bool
+	literal  bool
+}
+
+// commands is the registry, in the order help lists it.
+var commands []*command
+
+// commandIndex finds commands by name or alias.
+var commandIndex = make(map[string]*command)
+
+func init() {
+	commands = []*command{
+		{name: "login", args: "<walletAddress>", run: runLogin,
+			help: "Login to the game by signing a one-time challenge with your wallet"},
+		{name: "whoami", run: runWhoami,
+			help: "Show your wallet address and role"},
+		{name: "add", args: "<walletAddress> <playerName...>", perm: game.PermAddPlayer, run: runAdd,
+			help: "Add a new player"},
+		{name: "list", aliases: []string{"ls"}, format: true, run: runList,
+			help: "List all active players"},
+		{name: "allowlist", format: true, run: runAllowList,
+			help: "List all allowed wallet addresses"},
+		{name: "check", args: "<walletAddress>", format: true, run: runCheck,
+			help: "Show a player's tokens, XP, badges and levels"},
+		{name: "remove", args: "<walletAddress> [reason...]", perm: game.PermRemovePlayer, run: runRemove,
+			help: "Remove a player from the game, moving them to Purgatory"},
+		{name: "purgatory", perm: game.PermRemovePlayer, run: runPurgatory,
+			help: "List the removed players, why they were removed and any appeal"},
+		{name: "restore", args: "<walletAddress>", perm: game.PermRemovePlayer, run: runRestore,
+			help: "Bring a player back from Purgatory with their tokens and XP"},
+		{name: "appeal", args: "<walletAddress> <note...>", perm: game.PermRemovePlayer, run: runAppeal,
+			help: "Record a removed player's appeal"},
+		{name: "award", args: "<walletAddress> <gameTokens> <artTokens> <techTokens> <artXP> <gameXP> <techXP> [reason...]", perm: game.PermAward, run: runAward,
+			help: "Award tokens and XP to a player; negative amounts need a reason"},
+		{name: "history", args: "<walletAddress>", run: runHistory,
+			help: "Show every change to a player's tokens and XP"},
+		{name: "leaderboard", args: "<gamexp|artxp|techxp|tokens|riddles> [alltime|weekly]", run: runLeaderboard,
+			help: "Rank the players, over all time or the last seven days"},
+		{name: "chart", login: true, format: true, run: runChart,
+			help: "Display a chart of the logged-in player's tokens and XP"},
+		{name: "transfer", args: "<walletAddress> <game|art|tech> <amount>", perm: game.PermTrade, run: runTransfer,
+			help: "Send some of your tokens to another player"},
+		{name: "market", run: runMarket,
+			help: "List the open marketplace offers"},
+		{name: "offer", args: "<amount> <game|art|tech> for <amount> <game|art|tech>", perm: game.PermTrade, run: runOffer,
+			help: "Offer your tokens in exchange for others, e.g. offer 3 art for 5 tech"},
+		{name: "accept", args: "<offerID>", perm: game.PermTrade, run: runAccept,
+			help: "Accept an offer from the marketplace"},
+		{name: "cancel", args: "<offerID>", perm: game.PermTrade, run: runCancel,
+			help: "Cancel one of your offers and get your tokens back"},
+		{name: "save", args: "<filename>", perm: game.PermSaveGame, external: true, run: runSave,
+			help: "Save the game state to a file"},
+		{name: "load", args: "<filename>", perm: game.PermLoadGame, external: true, run: runLoad,
+			help: "Load the game state from a file"},
+		{name: "grant", args: "<walletAddress> <admin|gamemaster|player|guest>", perm: game.PermManageRoles, run: runGrant,
+			help: "Give a player a role"},
+		{name: "revoke", args: "<walletAddress>", perm: game.PermManageRoles, run: runRevoke,
+			help: "Take a player's role away, leaving them a plain player"},
+		{name: "locations", run: runLocations,
+			help: "List all available locations"},
+		{name: "read", args: "<locationName or number...>", run: runRead,
+			help: "Read the description of a location"},
+		{name: "riddles", run: runRiddles,
+			help: "List the riddles in the riddle bank by topic and difficulty"},
+		{name: "riddle", args: "<topic or riddleID>", login: true, run: runRiddle,
+			help: "Get your next riddle on a topic, easiest first, e.g. riddle go"},
+		{name: "hint", args: "<topic or riddleID>", login: true, run: runHint,
+			help: "Buy the next hint for your current riddle on a topic, or for one riddle"},
+		{name: "help", args: "[command]", run: runHelp,
+			help: "Display this help message, or the details of one command"},
+		{name: "exit", run: runExit,
+			help: "Exit the game"},
+	}
+	for _, c := range commands {
+		c.params = parseArgs(c.name, c.args)
+		for _, name := range append([]string{c.name}, c.aliases...) {
+			if _, taken := commandIndex[name]; taken {
+				panic("repl: command " + name + " registered twice")
+			}
+			commandIndex[name] = c
+		}
+	}
+}
+
+// parseArgs turns an argument schema into params. A malformed schema is a
+// bug in the registry, so it panics.
+func parseArgs(name, schema string) []param {
+	var params []param
+	// "<locationName or number...>" is one param, so split on the brackets
+	// rather than on spaces.
+	for rest := strings.TrimSpace(schema); rest != ""; rest = strings.TrimSpace(rest) {
+		var p param
+		switch rest[0] {
+		case '<', '[':
+			closing := map[byte]byte{'<': '>', '[': ']'}[rest[0]]
+			end := strings.IndexByte(rest, closing)
+			if end < 0 {
+				panic(fmt.Sprintf("repl: %s: unclosed %q in %q", name, rest[0], schema))
+			}
+			p.name, p.optional = rest[1:end], rest[0] == '['
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexByte(rest, ' ')
+			if end < 0 {
+				end = len(rest)
+			}
+			p.name, p.literal = rest[:end], true
+			rest = rest[end:]
+		}
+		if strings.HasSuffix(p.name, "...") {
+			p.name, p.rest = strings.TrimSuffix(p.name, "..."), true
+		}
+		if len(params) > 0 {
+			last := params[len(params)-1]
+			if last.rest || (last.optional && !p.optional) {
+				panic(fmt.Sprintf("repl: %s: %q can't follow an optional or rest argument", name, p.name))
+			}
+		}
+		params = append(params, p)
+	}
+	return params
+}
+
+// usage is the command as help and error messages show it.
+func (c *command) usage() string {
+	usage := c.name
+	if c.args != "" {
+		usage += " " + c.args
+	}
+	if c.format {
+		usage += " " + formatUsage
+	}
+	return usage
+}
+
+// bind matches the typed words to the schema, giving one value per param:
+// "" for a missing optional one, and the remaining words joined by spaces
+// for a rest param. It reports false when the words don't fit.
+func (c *command) bind(words []string) ([]string, bool) {
+	values := make([]string, len(c.params))
+	for i, p := range c.params {
+		if i >= len(words) {
+			if !p.optional {
+				return nil, false
+			}
+			continue
+		}
+		switch {
+		case p.rest:
+			values[i] = strings.Join(words[i:], " ")
+			return values, true
+		case p.literal && !strings.EqualFold(words[i], p.name):
+			return nil, false
+		}
+		values[i] = words[i]
+	}
+	return values, len(words) <= len(c.params)
+}
+
+// lookup finds a command by name or alias, ignoring case.
+func lookup(name string) (*command, bool) {
+	c, ok := commandIndex[strings.ToLower(name)]
+	return c, ok
+}
+
+// execute runs one tokenized command line.
+func (s *shell) execute(words []string) {
+	c, ok := lookup(words[0])
+	if !ok {
+		s.fail("Unknown command:", words[0])
+		return
+	}
+	if c.login && s.session == nil {
+		s.failf("You must be logged in to use %s.", c.name)
+		return
+	}
+	if c.perm != "" && !s.g.Can(s.session, c.perm) {
+		s.failf("You are not allowed to use %s (** RESTRICTED: %s **).", c.name, minimumRole(c.perm))
+		return
+	}
+	words, s.format = words[1:], formatText
+	if c.format {
+		var ok bool
+		if s.format, words, ok = takeFormat(words); !ok {
+			s.fail("Usage:", c.usage())
+			return
+		}
+	}
+	args, ok := c.bind(words)
+	if !ok {
+		s.fail("Usage:", c.usage())
+		return
+	}
+	if c.external && s.dryRun {
+		fmt.Println("Dry run: skipping", c.name, strings.Join(words, " "))
+		return
+	}
+	c.run(s, args)
+}
+
+// minimumRole is the least powerful role that holds the permission.
+func minimumRole(p game.Permission) game.Role {
+	for _, role := range []game.Role{game.RoleGuest, game.RolePlayer, game.RoleGamemaster} {
+		if role.Can(p) {
+			return role
+		}
+	}
+	return game.RoleAdmin
+}
+
+// helpLine is a command's one-line summary, e.g.
+// "save <filename> - Save the game state to a file (** RESTRICTED: gamemaster **)".
+func (c *command) helpLine(color palette) string {
+	line := c.usage() + " - " + c.help
+	if c.perm != "" {
+		line += " " + color.yellow(fmt.Sprintf("(** RESTRICTED: %s **)", minimumRole(c.perm)))
+	} else if c.login {
+		line += " " + color.yellow("(** login required **)")
+	}
+	return line
+}
+
+// allowed reports whether the session may run the command right now.
+func (s *shell) allowed(c *command) bool {
+	if c.login && s.session == nil {
+		return false
+	}
+	return c.perm == "" || s.g.Can(s.session, c.perm)
+}
+
+func runHelp(s *shell, args []string) {
+	if args[0] != "" {
+		c, ok := lookup(args[0])
+		if !ok {
+			s.fail("Unknown command:", args[0])
+			return
+		}
+		fmt.Println(c.helpLine(s.color))
+		if len(c.aliases) > 0 {
+			fmt.Println("Also:", strings.Join(c.aliases, ", "))
+		}
+		return
+	}
+	fmt.Println("Commands:")
+	for _, c := range commands {
+		line := c.helpLine(s.color)
+		if !s.allowed(c) {
+			line = s.color.dim(line) // Greyed out until you log in or get the role
+		}
+		fmt.Println(line)
+	}
+	fmt.Println(`Wrap arguments with spaces in quotes, e.g. add 0xAbc "Captain Nova".`)
+}
+
+func runLogin(s *shell, args []string) {
+	walletAddress := args[0]
+	message, err := s.challenges.Issue(walletAddress)
+	if err != nil {
+		s.fail("Login failed:", err)
+		return
+	}
+	fmt.Printf("Sign this message with your wallet (personal_sign) and paste the signature:\n\n%s\n\n", message)
+	signature, _ := s.in.ReadLine("signature> ")
+	if err := s.challenges.VerifyLogin(walletAddress, strings.TrimSpace(signature)); err != nil {
+		s.fail("Login failed:", err)
+		return
+	}
+	if !s.g.Login(walletAddress) {
+		s.fail("Login failed.")
+		return
+	}
+	fmt.Println(s.color.green("Login successful. Welcome " + walletAddress))
+	s.session = &game.Session{WalletAddress: walletAddress, CreatedAt: time.Now()}
+	// prompt user to load a game state, listing the game states available (files in the directory not ending in .go)
+	files, err := ioutil.ReadDir(".")
+	if err != nil {
+		s.fail("Error reading directory:", err)
+		return
+	}
+
+	fmt.Println("Available game states:")
+	for _, file := range files {
+		if !file.IsDir() && !strings.HasSuffix(file.Name(), ".go") {
+			fmt.Println(file.Name())
+		}
+	}
+}
+
+func runWhoami(s *shell, args []string) {
+	if s.session == nil {
+		fmt.Println("Not logged in. Role:", game.RoleGuest)
+		return
+	}
+	fmt.Printf("%s (%s)\n", s.session.WalletAddress, s.g.RoleOf(s.session.WalletAddress))
+}
+
+func runAdd(s *shell, args []string) {
+	walletAddress, playerName := args[0], args[1]
+	if err := s.g.AddPlayer(walletAddress, playerName); err != nil {
+		s.fail("Error adding player:", err)
+	} else {
+		fmt.Printf("Player %s added with starting tokens.\n", playerName)
+	}
+}
+
+func runList(s *shell, args []string) {
+	players := s.g.ListPlayers()
+	sort.Slice(players, func(i, j int) bool { return players[i].WalletAddress < players[j].WalletAddress })
+	if s.format != formatText {
+		list := records{v: players, header: playerColumns}
+		for _, player := range players {
+			list.rows = append(list.rows, playerRow(player))
+		}
+		s.write(list)
+		return
+	}
+	fmt.Println("Players:")
+	for _, player := range players {
+		fmt.Printf("%s (%s)\n", player.PlayerName, player.WalletAddress)
+	}
+}
+
+// allowedWallet is an allow list entry as --format shows it.
+type allowedWallet struct {
+	WalletAddress string
+}
+
+func runAllowList(s *shell, args []string) {
+	wallets := s.g.AllowedWallets()
+	sort.Strings(wallets)
+	if s.format != formatText {
+		entries := make([]allowedWallet, 0, len(wallets))
+		var rows [][]string
+		for _, walletAddress := range wallets {
+			entries = append(entries, allowedWallet{walletAddress})
+			rows = append(rows, []string{walletAddress})
+		}
+		s.write(records{v: entries, header: []string{"WalletAddress"}, rows: rows})
+		return
+	}
+	fmt.Println("Allowed Wallet Addresses:")
+	for _, walletAddress := range wallets {
+		fmt.Println(walletAddress)
+	}
+}
+
+func runCheck(s *shell, args []string) {
+	walletAddress := args[0]
+	player, exists := s.g.Player(walletAddress)
+	if !exists {
+		s.fail("Player not found.")
+		return
+	}
+	levels, _ := s.g.Levels(walletAddress)
+	if s.format != formatText {
+		s.write(statusRecords(player, levels))
+		return
+	}
+	fmt.Printf("Player: %s\n", player.PlayerName)
+	fmt.Printf("Game Tokens: %d\n", player.GameTokens)
+	fmt.Printf("Art Tokens: %d\n", player.ArtTokens)
+	fmt.Printf("Tech Tokens: %d\n", player.TechTokens)
+	fmt.Printf("Art XP: %d\n", player.ArtXP)
+	fmt.Printf("Game XP: %d\n", player.GameXP)
+	fmt.Printf("Tech XP: %d\n", player.TechXP)
+	fmt.Printf("Riddle Score: %d\n", player.RiddleScore)
+	fmt.Printf("Badges: %s\n", describeBadges(player))
+	for _, track := range game.Tracks {
+		fmt.Printf("%s Level: %s\n", trackTitles[track], describeProgress(levels[track]))
+	}
+}
+
+func runRemove(s *shell, args []string) {
+	walletAddress, reason := args[0], args[1]
+	if err := s.g.RemovePlayer(s.session.WalletAddress, walletAddress, reason); err != nil {
+		s.fail("Error removing player:", err)
+	} else {
+		fmt.Printf("Player %s has been moved to Purgatory.\n", walletAddress)
+	}
+}
+
+func runPurgatory(s *shell, args []string) {
+	players := s.g.PurgatoryPlayers()
+	if len(players) == 0 {
+		fmt.Println("Purgatory is empty.")
+		return
+	}
+	fmt.Println("Purgatory:")
+	for _, player := range players {
+		printRemoval(player)
+	}
+}
+
+func runRestore(s *shell, args []string) {
+	if err := s.g.RestorePlayer(args[0]); err != nil {
+		s.fail("Error restoring player:", err)
+	} else {
+		fmt.Printf("Player %s is back from Purgatory.\n", args[0])
+	}
+}
+
+func runAppeal(s *shell, args []string) {
+	if err := s.g.AppealRemoval(args[0], args[1]); err != nil {
+		s.fail("Error recording appeal:", err)
+	} else {
+		fmt.Printf("Appeal recorded for %s.\n", args[0])
+	}
+}
+
+func runAward(s *shell, args []string) {
+	walletAddress := args[0]
+	var amounts [6]int
+	for i := range amounts {
+		amount, err := strconv.Atoi(args[1+i])
+		if err != nil {
+			s.failf("%q is not a number.", args[1+i])
+			return
+		}
+		amounts[i] = amount
+	}
+	delta := game.Balances{GameTokens: amounts[0], ArtTokens: amounts[1], TechTokens: amounts[2], ArtXP: amounts[3], GameXP: amounts[4], TechXP: amounts[5]}
+	reason := args[7]
+	if err := s.g.AwardTokensXP(s.session.WalletAddress, walletAddress, delta, reason); err != nil {
+		s.fail("Error awarding tokens and XP:", err)
+	} else {
+		fmt.Println("Awards and XP have been updated for", walletAddress)
+	}
+}
+
+func runHistory(s *shell, args []string) {
+	entries := s.g.History(args[0])
+	if len(entries) == 0 {
+		fmt.Println("No history for", args[0])
+		return
+	}
+	for _, entry := range entries {
+		printLedgerEntry(entry)
+	}
+}
+
+func runLeaderboard(s *shell, args []string) {
+	metric, err := game.ParseMetric(args[0])
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	period, err := game.ParsePeriod(args[1])
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	fmt.Printf("Leaderboard: %s (%s)\n", metric, period)
+	for _, row := range s.g.Leaderboard(metric, period) {
+		fmt.Printf("%3d. %s (%s) - %d\n", row.Rank, row.PlayerName, row.WalletAddress, row.Score)
+	}
+}
+
+func runChart(s *shell, args []string) {
+	currentPlayer, ok := s.g.Player(s.session.WalletAddress)
+	if !ok {
+		s.fail("Current user not found in players.")
+		return
+	}
+	levels, _ := s.g.Levels(s.session.WalletAddress)
+	if s.format != formatText {
+		s.write(statusRecords(currentPlayer, levels))
+		return
+	}
+	printChart(&currentPlayer, levels)
+}
+
+func runTransfer(s *shell, args []string) {
+	token, err := game.ParseToken(args[1])
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	amount, err := strconv.Atoi(args[2])
+	if err != nil {
+		s.fail(game.ErrInvalidAmount)
+		return
+	}
+	if err := s.g.Transfer(s.session.WalletAddress, args[0], token, amount); err != nil {
+		s.fail("Error transferring tokens:", err)
+	} else {
+		fmt.Printf("Sent %d %s tokens to %s.\n", amount, token, args[0])
+	}
+}
+
+func runMarket(s *shell, args []string) {
+	offers := s.g.OpenOffers()
+	if len(offers) == 0 {
+		fmt.Println("No open offers.")
+		return
+	}
+	fmt.Println("Open offers:")
+	for _, offer := range offers {
+		fmt.Printf("#%d  %d %s for %d %s  (from %s)\n", offer.ID, offer.GiveAmount, offer.Give, offer.WantAmount, offer.Want, offer.Seller)
+	}
+}
+
+func runOffer(s *shell, args []string) {
+	giveAmount, err1 := strconv.Atoi(args[0])
+	wantAmount, err2 := strconv.Atoi(args[3])
+	if err1 != nil || err2 != nil {
+		s.fail(game.ErrInvalidAmount)
+		return
+	}
+	give, err1 := game.ParseToken(args[1])
+	want, err2 := game.ParseToken(args[4])
+	if err1 != nil || err2 != nil {
+		s.fail(game.ErrUnknownToken)
+		return
+	}
+	offer, err := s.g.PostOffer(s.session.WalletAddress, give, giveAmount, want, wantAmount)
+	if err != nil {
+		s.fail("Error posting offer:", err)
+	} else {
+		fmt.Printf("Offer #%d posted. Your %d %s tokens are held until it is accepted or cancelled.\n", offer.ID, offer.GiveAmount, offer.Give)
+	}
+}
+
+// parseOfferID accepts "3" or "#3".
+func (s *shell) parseOfferID(arg string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil {
+		s.fail(game.ErrOfferNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func runAccept(s *shell, args []string) {
+	id, ok := s.parseOfferID(args[0])
+	if !ok {
+		return
+	}
+	offer, err := s.g.AcceptOffer(s.session.WalletAddress, id)
+	if err != nil {
+		s.fail("Error accepting offer:", err)
+	} else {
+		fmt.Printf("You traded %d %s for %d %s with %s.\n", offer.WantAmount, offer.Want, offer.GiveAmount, offer.Give, offer.Seller)
+	}
+}
+
+func runCancel(s *shell, args []string) {
+	id, ok := s.parseOfferID(args[0])
+	if !ok {
+		return
+	}
+	if _, err := s.g.CancelOffer(s.session.WalletAddress, id); err != nil {
+		s.fail("Error cancelling offer:", err)
+	} else {
+		fmt.Printf("Offer #%d cancelled and your tokens returned.\n", id)
+	}
+}
+
+func runSave(s *shell, args []string) {
+	filename := args[0]
+	if err := s.g.Save(filename); err != nil {
+		s.fail("Error saving game:", err)
+	} else {
+		fmt.Println("Game saved to", filename, "successfully")
+	}
+}
+
+func runLoad(s *shell, args []string) {
+	filename := args[0]
+	loadedGame, err := game.Load(filename)
+	if err == nil {
+		err = s.g.Replace(loadedGame) // In place, so the HTTP server sees the loaded state too
+	}
+	if err != nil {
+		s.fail("Error loading game:", err)
+	} else {
+		fmt.Println("Game loaded from", filename, "successfully")
+	}
+}
+
+func runGrant(s *shell, args []string) {
+	role, err := game.ParseRole(args[1])
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	if err := s.g.SetRole(args[0], role); err != nil {
+		s.fail("Error granting role:", err)
+	} else {
+		fmt.Printf("%s is now %s.\n", args[0], role)
+	}
+}
+
+func runRevoke(s *shell, args []string) {
+	if err := s.g.SetRole(args[0], game.RolePlayer); err != nil {
+		s.fail("Error revoking role:", err)
+	} else {
+		fmt.Printf("%s is now %s.\n", args[0], game.RolePlayer)
+	}
+}
+
+// locationNames lists the locations in a fixed order, so the numbers shown
+// by "locations" still work for "read".
+func locationNames() []string {
+	names := make([]string, 0, len(content.Locations))
+	for name := range content.Locations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func runLocations(s *shell, args []string) {
+	fmt.Println("Choose a location by number or name:")
+	for i, name := range locationNames() {
+		fmt.Printf("%d. %s\n", i+1, name)
+	}
+}
+
+func runRead(s *shell, args []string) {
+	input := args[0]
+	name := input
+	if num, err := strconv.Atoi(input); err == nil {
+		// Input is a number, find the corresponding location by index
+		if names := locationNames(); num >= 1 && num <= len(names) {
+			name = names[num-1]
+		}
+	}
+	read, ok := content.Locations[name]
+	if !ok {
+		s.fail("Location not found.")
+		return
+	}
+	fmt.Printf("%s: %s - %s\n", read.Name, read.Description, read.Challenge)
+	if s.session != nil {
+		s.g.VisitLocation(s.session.WalletAddress, read.Name) // Only players get credit; guests can still read
+	}
+}
+
+func runRiddles(s *shell, args []string) {
+	bank := s.g.RiddleBank()
+	for _, topic := range bank.Topics() {
+		fmt.Printf("%s:\n", topic)
+		for _, riddle := range bank.Topic(topic) {
+			status := ""
+			if s.session != nil {
+				status = describeAttempt(riddle, s.g.RiddleAttempt(s.session.WalletAddress, riddle.ID))
+			}
+			fmt.Printf("  %s - %s%s\n", riddle.ID, riddle.Difficulty, status)
+		}
+	}
+}
+
+// describeAttempt is the player's progress on a riddle, for the riddles
+// list: empty until they have answered it.
+func describeAttempt(riddle game.Riddle, attempt game.RiddleAttempt) string {
+	switch {
+	case attempt.Solved:
+		return " (solved)"
+	case riddle.Locked(attempt):
+		return " (out of tries)"
+	case attempt.Tries == 1:
+		return " (1 try)"
+	case attempt.Tries > 1:
+		return fmt.Sprintf(" (%d tries)", attempt.Tries)
+	}
+	return ""
+}
+
+// pickRiddle finds the riddle a riddle or hint command means: the next
+// one on a topic, or one riddle by ID. It prints why there is none.
+func pickRiddle(s *shell, topicOrID string) (game.Riddle, bool) {
+	if _, ok := s.g.Player(s.session.WalletAddress); !ok {
+		s.fail("Current user not found in players.")
+		return game.Riddle{}, false
+	}
+	var riddle game.Riddle
+	var err error
+	if slices.Contains(s.g.RiddleBank().Topics(), topicOrID) {
+		riddle, err = s.g.NextRiddle(s.session.WalletAddress, topicOrID)
+	} else {
+		riddle, err = s.g.Riddle(s.session.WalletAddress, topicOrID)
+	}
+	switch err {
+	case nil:
+		return riddle, true
+	case game.ErrRiddleAttempted:
+		fmt.Println("You've already solved this riddle or used up your tries. Moving on...")
+	case game.ErrTopicExhausted:
+		fmt.Printf("You've solved or used up every %s riddle. Moving on...\n", topicOrID)
+	default:
+		s.fail(err)
+	}
+	return game.Riddle{}, false
+}
+
+func runRiddle(s *shell, args []string) {
+	riddle, ok := pickRiddle(s, args[0])
+	if !ok {
+		return
+	}
+	attempt := s.g.RiddleAttempt(s.session.WalletAddress, riddle.ID)
+	if wait := time.Until(riddle.RetryAt(attempt)); wait > 0 {
+		fmt.Printf("Give it a moment: you can answer the %s riddle again in %s.\n", riddle.ID, wait.Round(time.Second))
+		return
+	}
+	header := fmt.Sprintf("[%s, %s] attempt %d", riddle.ID, riddle.Difficulty, attempt.Tries+1)
+	if riddle.MaxAttempts > 0 {
+		header += fmt.Sprintf(" of %d", riddle.MaxAttempts)
+	}
+	fmt.Println(header)
+	fmt.Println(riddle.Prompt)
+	if riddle.Code != "" {
+		fmt.Println()
+		for _, line := range strings.Split(strings.TrimRight(riddle.Code, "\n"), "\n") {
+			fmt.Println("\t" + line)
+		}
+	}
+	for _, hint := range riddle.RevealedHints(attempt) {
+		fmt.Println("Hint: " + hint)
+	}
+	var answer string
+	if riddle.Answer.Match == game.MatchGo {
+		answer = readCode(s)
+	} else {
+		answer, _ = s.in.ReadLine("> ")
+	}
+	if strings.TrimSpace(answer) == "" {
+		fmt.Println("No answer, no try. Come back when you've got one.")
+		return
+	}
+	result, err := s.g.AnswerRiddle(s.session.WalletAddress, riddle.ID, answer)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	if result.Output != "" {
+		fmt.Println(result.Output)
+	}
+	fmt.Println(result.Message)
+	switch {
+	case result.Correct && result.Paid != (game.Balances{}):
+		fmt.Printf("Reward: %s\n", describeBalances(result.Paid))
+	case result.Correct:
+	case result.Locked:
+		fmt.Println("That was your last try at this riddle.")
+	case result.AttemptsLeft > 0:
+		fmt.Printf("%d of %d tries left", result.AttemptsLeft, riddle.MaxAttempts)
+		if riddle.Cooldown > 0 {
+			fmt.Printf("; the next one opens in %s", riddle.Cooldown)
+		}
+		fmt.Println(".")
+	case riddle.Cooldown > 0:
+		fmt.Printf("You can try again in %s.\n", riddle.Cooldown)
+	}
+}
+
+// readCode reads a Go answer a line at a time, up to a line holding only
+// a dot or the end of input.
+func readCode(s *shell) string {
+	fmt.Println("Type your Go code, then a line with just a dot.")
+	var lines []string
+	for {
+		line, err := s.in.ReadLine(". ")
+		if err != nil || strings.TrimSpace(line) == "." {
+			return strings.Join(lines, "\n")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func runHint(s *shell, args []string) {
+	riddle, ok := pickRiddle(s, args[0])
+	if !ok {
+		return
+	}
+	hints, err := s.g.RiddleHint(s.session.WalletAddress, riddle.ID)
+	switch err {
+	case nil:
+	case game.ErrNoMoreHints:
+		fmt.Printf("That's every hint for the %s riddle. You're on your own now.\n", riddle.ID)
+		return
+	default:
+		s.fail(err)
+		return
+	}
+	header := fmt.Sprintf("[%s] hint %d of %d", riddle.ID, len(hints), len(riddle.Hints))
+	if cost := riddle.HintCost; cost != (game.Balances{}) {
+		// Hints cost tokens only; show them as the charge they were.
+		header += ": " + describeBalances(game.Balances{GameTokens: -cost.GameTokens, ArtTokens: -cost.ArtTokens, TechTokens: -cost.TechTokens})
+	}
+	fmt.Println(header)
+	fmt.Println(hints[len(hints)-1])
+}
+
+func runExit(s *shell, args []string) {
+	fmt.Println("Exciting game. May all your hooties, and this is important, dooty!")
+	s.done = true
+}
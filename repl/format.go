@@ -0,0 +1,120 @@
+// Description: This file contains the output formats for the listing commands. list, check, chart and allowlist print JSON, CSV or a table, with the same field names as the API.
+
+package repl
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// outputFormat is what --format asks for. The zero value is the usual
+// text meant for people.
+type outputFormat string
+
+const (
+	formatText  outputFormat = ""
+	formatJSON  outputFormat = "json"
+	formatCSV   outputFormat = "csv"
+	formatTable outputFormat = "table"
+)
+
+var outputFormats = []string{string(formatJSON), string(formatCSV), string(formatTable)}
+
+// formatUsage is added to the usage line of commands that take --format.
+var formatUsage = "[--format " + strings.Join(outputFormats, "|") + "]"
+
+// takeFormat removes "--format x" or "--format=x" from the words and
+// returns x. It reports false when the value is missing or unknown.
+func takeFormat(words []string) (outputFormat, []string, bool) {
+	format := formatText
+	var rest []string
+	for i := 0; i < len(words); i++ {
+		value, ok := strings.CutPrefix(words[i], "--format=")
+		if !ok {
+			if words[i] != "--format" {
+				rest = append(rest, words[i])
+				continue
+			}
+			if i+1 == len(words) {
+				return "", nil, false
+			}
+			i++
+			value = words[i]
+		}
+		format = outputFormat(strings.ToLower(value))
+		if !slices.Contains(outputFormats, string(format)) {
+			return "", nil, false
+		}
+	}
+	return format, rest, true
+}
+
+// records is data laid out for --format: v is encoded as JSON, and
+// header and rows make the CSV and the table.
+type records struct {
+	v      interface{}
+	header []string
+	rows   [][]string
+}
+
+// write prints the records in the format, which must not be formatText.
+func (r records) write(format outputFormat) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.v)
+	case formatCSV:
+		w := csv.NewWriter(os.Stdout)
+		w.Write(r.header)
+		w.WriteAll(r.rows)
+		return w.Error()
+	case formatTable:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(r.header, "\t"))
+		for _, row := range r.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// playerColumns are the CSV and table columns for a player, named like the
+// fields of the Player JSON encoding. Maps such as Badges only appear in
+// the JSON.
+var playerColumns = []string{"WalletAddress", "PlayerName", "Role", "GameTokens", "ArtTokens", "TechTokens", "ArtXP", "GameXP", "TechXP", "RiddleScore"}
+
+func playerRow(player game.Player) []string {
+	row := []string{player.WalletAddress, player.PlayerName, string(player.Role)}
+	for _, n := range []int{player.GameTokens, player.ArtTokens, player.TechTokens, player.ArtXP, player.GameXP, player.TechXP, player.RiddleScore} {
+		row = append(row, strconv.Itoa(n))
+	}
+	return row
+}
+
+// playerStatus is a player with their levels, encoded like GET /status.
+type playerStatus struct {
+	game.Player
+	Levels game.PlayerLevels
+}
+
+// statusRecords lays out one player and their levels. The level columns
+// are named after the JSON path, e.g. "Levels.art.Level".
+func statusRecords(player game.Player, levels game.PlayerLevels) records {
+	header := append([]string(nil), playerColumns...)
+	row := playerRow(player)
+	for _, track := range game.Tracks {
+		header = append(header, "Levels."+track+".Level", "Levels."+track+".XPToNext")
+		row = append(row, strconv.Itoa(levels[track].Level), strconv.Itoa(levels[track].XPToNext))
+	}
+	return records{v: playerStatus{Player: player, Levels: levels}, header: header, rows: [][]string{row}}
+}
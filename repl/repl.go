@@ -0,0 +1,286 @@
+// Package repl is the interactive command prompt for "Drive, Astrovan, Drive".
+package repl
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// Options tune the prompt.
+type Options struct {
+	// HistoryFile keeps command history between sessions when the prompt
+	// runs on a terminal. Empty means no history is kept.
+	HistoryFile string
+}
+
+// shell is the state of one interactive session.
+type shell struct {
+	g          *game.Game
+	challenges *game.ChallengeStore
+	in         lineReader
+	color      palette
+	session    *game.Session // The REPL's own session, separate from any HTTP sessions
+	done       bool          // Set by "exit"
+	failed     bool          // Set by fail, so a script can stop at the first error
+	dryRun     bool          // Skip commands that touch files outside the game
+	format     outputFormat  // The running command's --format
+}
+
+// Run runs the interactive game loop on in until "exit" or end of input.
+// On a terminal it edits lines with history and tab completion and colors
+// its output; otherwise it reads plain lines and prints plain text.
+func Run(g *game.Game, challenges *game.ChallengeStore, in io.Reader, opts Options) {
+	s := &shell{g: g, challenges: challenges, color: newPalette()}
+	s.in = newLineReader(in, opts.HistoryFile, completer{s})
+	defer s.in.Close()
+	fmt.Println(`Welcome to Ceptor Club's "Drive, Astrovan, Drive"!
+
+You see Grampa the Astrovan rolling up, with your old friend Tippi at the wheel. "Hop in, no time to explain!" he shouts, and then as you take your seat, almost immediately hits the accelerator.
+
+Smoke fills the interior and you are transported into the adventure: 
+Save the multiverse from the Quantum Digitizers and their insidious ScanBots!`)
+	// Tutorial Decision
+	fmt.Println()
+	input, _ := s.in.ReadLine("Do you want to skip the tutorial? (Y/n): ")
+	input = strings.TrimSpace(input)
+
+	// Tutorial Process
+	if strings.ToLower(input) == "n" {
+		// Start Tutorial
+		startTutorial()
+	} else {
+		// Skip Tutorial
+		fmt.Println("Skipping tutorial. Fastening seat belts...")
+		// Any additional setup before starting the game can be placed here.
+	}
+
+	for !s.done {
+		printNotifications(g, s.session)
+		input, err := s.in.ReadLine("> ")
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			s.fail("Error reading input:", err)
+			continue
+		}
+
+		// Parse input for commands
+		words, err := tokenize(input)
+		if err != nil {
+			s.fail("Error:", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		s.in.Remember(strings.TrimSpace(input))
+		s.execute(words)
+	}
+}
+
+// fail prints an error message, in red on a terminal, and marks the
+// command as failed.
+func (s *shell) fail(a ...interface{}) {
+	s.failed = true
+	fmt.Println(s.color.red(strings.TrimSuffix(fmt.Sprintln(a...), "\n")))
+}
+
+func (s *shell) failf(format string, a ...interface{}) {
+	s.fail(fmt.Sprintf(format, a...))
+}
+
+// write prints the records in the running command's --format.
+func (s *shell) write(r records) {
+	if err := r.write(s.format); err != nil {
+		s.fail("Error writing output:", err)
+	}
+}
+
+// startTutorial encapsulates the tutorial logic.
+func startTutorial() {
+	fmt.Println("\n--- Welcome to the Tutorial! ---")
+	fmt.Println(`1. Setting Availability and Preferences
+Your presence in the Astrovan isn't just about being there; it's about making sure you're there at the right time. This is where you set your game availability.`)
+
+	// Simulate interaction or more explanations
+
+	fmt.Println(`2. Registering for Game Sessions
+Excited for an adventure? Here's how you register for the next session of Astrovan. It's simpler than dodging ScanBots.`)
+
+	// More tutorial content...
+
+	fmt.Println("\nTutorial completed! Are you ready to start your adventure, or do you need help (type 'help' for more commands)?")
+	// Based on further user input, you can repeat or exit the tutorial.
+}
+
+// Generates a scaled bar based on the maximum value in the dataset.
+func generateScaledBar(value, maxValue int) string {
+	const scaleSize = 10
+	if maxValue == 0 {
+		return strings.Repeat(" ", scaleSize)
+	}
+	barLength := int((float64(value) / float64(maxValue)) * scaleSize)
+	return strings.Repeat("=", barLength) + strings.Repeat(" ", scaleSize-barLength)
+}
+
+// Helper function to find the maximum of three integers.
+func max(a, b, c int) int {
+	return int(math.Max(math.Max(float64(a), float64(b)), float64(c)))
+}
+
+// printLedgerEntry prints one line of a player's history, e.g.
+// "2024-05-01 18:30  +5 Game XP, +5 Tech XP  by system: solved the go riddle".
+func printLedgerEntry(entry game.LedgerEntry) {
+	fmt.Println(describeLedgerEntry(entry))
+}
+
+func describeLedgerEntry(entry game.LedgerEntry) string {
+	when := "(opening)       "
+	if !entry.Time.IsZero() {
+		when = entry.Time.Local().Format("2006-01-02 15:04")
+	}
+	actor := entry.Actor
+	if actor == "" {
+		actor = "unknown" // Awards recorded before the ledger kept track
+	}
+	line := fmt.Sprintf("%s  %s  by %s", when, describeBalances(entry.Balances), actor)
+	if entry.Reason != "" {
+		line += ": " + entry.Reason
+	}
+	return line
+}
+
+// describeBalances lists the non-zero balances as signed changes, e.g.
+// "-1 Game Tokens, +5 Game XP".
+func describeBalances(b game.Balances) string {
+	var changes []string
+	for _, change := range []struct {
+		name   string
+		amount int
+	}{
+		{"Game Tokens", b.GameTokens},
+		{"Art Tokens", b.ArtTokens},
+		{"Tech Tokens", b.TechTokens},
+		{"Art XP", b.ArtXP},
+		{"Game XP", b.GameXP},
+		{"Tech XP", b.TechXP},
+	} {
+		if change.amount != 0 {
+			changes = append(changes, fmt.Sprintf("%+d %s", change.amount, change.name))
+		}
+	}
+	return strings.Join(changes, ", ")
+}
+
+// printRemoval prints a player in Purgatory and the note on their removal.
+func printRemoval(player game.Player) {
+	fmt.Printf("%s (%s)\n", player.PlayerName, player.WalletAddress)
+	removal := player.Removal
+	if removal == nil {
+		removal = &game.Removal{}
+	}
+	removedAt, removedBy, reason := "at an unknown time", "", "no reason given"
+	if !removal.RemovedAt.IsZero() {
+		removedAt = removal.RemovedAt.Local().Format("2006-01-02 15:04")
+	}
+	if removal.RemovedBy != "" {
+		removedBy = " by " + removal.RemovedBy
+	}
+	if removal.Reason != "" {
+		reason = removal.Reason
+	}
+	fmt.Printf("  Removed %s%s: %s\n", removedAt, removedBy, reason)
+	if removal.Appeal != "" {
+		fmt.Printf("  Appeal: %s\n", removal.Appeal)
+	}
+}
+
+// describeBadges lists the player's badges by name, in the order of
+// game.Achievements.
+func describeBadges(player game.Player) string {
+	var names []string
+	for _, achievement := range game.Achievements {
+		if _, unlocked := player.Badges[achievement.ID]; unlocked {
+			names = append(names, achievement.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "none yet"
+	}
+	return strings.Join(names, ", ")
+}
+
+// printNotifications shows the logged in player anything new, such as
+// badges they just unlocked.
+func printNotifications(g *game.Game, session *game.Session) {
+	if session == nil {
+		return
+	}
+	notifications, _ := g.TakeNotifications(session.WalletAddress)
+	for _, notification := range notifications {
+		fmt.Println("*", notification)
+	}
+}
+
+// trackTitles are the level tracks as shown to players.
+var trackTitles = map[string]string{
+	game.TrackArt:  "Art",
+	game.TrackGame: "Game",
+	game.TrackTech: "Tech",
+	game.TrackClub: "Club",
+}
+
+// describeProgress reads e.g. "3 (120 XP to level 4)".
+func describeProgress(p game.Progress) string {
+	if p.NextLevelXP == 0 {
+		return fmt.Sprintf("%d (top level)", p.Level)
+	}
+	return fmt.Sprintf("%d (%d XP to level %d)", p.Level, p.XPToNext, p.Level+1)
+}
+
+// printChart prints an ASCII chart of the player's tokens, XP and levels.
+func printChart(player *game.Player, levels game.PlayerLevels) {
+	if player == nil {
+		fmt.Println("No player data available to generate chart.")
+		return
+	}
+	// Find the max values for scaling
+	maxTokens := max(player.GameTokens, player.ArtTokens, player.TechTokens)
+	maxXP := max(player.ArtXP, player.GameXP, player.TechXP)
+
+	// Generate the bars
+	gameTokensBar := generateScaledBar(player.GameTokens, maxTokens)
+	artTokensBar := generateScaledBar(player.ArtTokens, maxTokens)
+	techTokensBar := generateScaledBar(player.TechTokens, maxTokens)
+
+	gameXPBar := generateScaledBar(player.GameXP, maxXP)
+	artXPBar := generateScaledBar(player.ArtXP, maxXP)
+	techXPBar := generateScaledBar(player.TechXP, maxXP)
+
+	// Print the bars
+	fmt.Printf("Tokens\n")
+	fmt.Printf("Game Tokens: [%s] %d\n", gameTokensBar, player.GameTokens)
+	fmt.Printf("Art Tokens:  [%s] %d\n", artTokensBar, player.ArtTokens)
+	fmt.Printf("Tech Tokens: [%s] %d\n", techTokensBar, player.TechTokens)
+
+	fmt.Printf("\nExperience Points\n")
+	fmt.Printf("Game XP: [%s] %d\n", gameXPBar, player.GameXP)
+	fmt.Printf("Art XP:  [%s] %d\n", artXPBar, player.ArtXP)
+	fmt.Printf("Tech XP: [%s] %d\n", techXPBar, player.TechXP)
+
+	// Each bar shows the way through the current level
+	fmt.Printf("\nLevels\n")
+	for _, track := range game.Tracks {
+		p := levels[track]
+		bar := generateScaledBar(1, 1)
+		if p.NextLevelXP != 0 {
+			bar = generateScaledBar(p.XP-p.LevelXP, p.NextLevelXP-p.LevelXP)
+		}
+		fmt.Printf("%-5s [%s] %s\n", trackTitles[track]+":", bar, describeProgress(p))
+	}
+}
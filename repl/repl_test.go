@@ -0,0 +1,180 @@
+package repl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+func TestTokenize(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want []string
+		err  error
+	}{
+		{"  read   Neon Forest \n", []string{"read", "Neon", "Forest"}, nil},
+		{`add 0xAbc "Captain Nova"`, []string{"add", "0xAbc", "Captain Nova"}, nil},
+		{`add 0xAbc 'It''s "me"'`, []string{"add", "0xAbc", `Its "me"`}, nil},
+		{`say "a \"b\" \c"`, []string{"say", `a "b" \c`}, nil},
+		{`say Captain\ Nova`, []string{"say", "Captain Nova"}, nil},
+		{`remove 0xAbc ""`, []string{"remove", "0xAbc", ""}, nil},
+		{"", nil, nil},
+		{`add "Captain`, nil, errUnterminatedQuote},
+		{`add Captain\`, nil, errTrailingBackslash},
+	} {
+		got, err := tokenize(tc.line)
+		if err != tc.err || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("tokenize(%q) = %q, %v; want %q, %v", tc.line, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestBind(t *testing.T) {
+	for _, tc := range []struct {
+		words []string
+		want  []string
+		ok    bool
+	}{
+		{[]string{"remove", "0xAbc"}, []string{"0xAbc", ""}, true},
+		{[]string{"remove", "0xAbc", "too", "loud"}, []string{"0xAbc", "too loud"}, true},
+		{[]string{"remove"}, nil, false},
+		{[]string{"offer", "3", "art", "FOR", "5", "tech"}, []string{"3", "art", "FOR", "5", "tech"}, true},
+		{[]string{"offer", "3", "art", "to", "5", "tech"}, nil, false},
+		{[]string{"leaderboard", "gamexp", "weekly", "extra"}, nil, false},
+		{[]string{"ls"}, []string{}, true},
+	} {
+		c, ok := lookup(tc.words[0])
+		if !ok {
+			t.Fatalf("%s is not registered", tc.words[0])
+		}
+		got, ok := c.bind(tc.words[1:])
+		if ok != tc.ok || (ok && !reflect.DeepEqual(got, tc.want)) {
+			t.Errorf("bind(%q) = %q, %v; want %q, %v", tc.words, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestTakeFormat(t *testing.T) {
+	for _, tc := range []struct {
+		words  []string
+		format outputFormat
+		rest   []string
+		ok     bool
+	}{
+		{[]string{"0xAbc"}, formatText, []string{"0xAbc"}, true},
+		{[]string{"0xAbc", "--format", "JSON"}, formatJSON, []string{"0xAbc"}, true},
+		{[]string{"--format=csv", "0xAbc"}, formatCSV, []string{"0xAbc"}, true},
+		{[]string{"--format", "table"}, formatTable, nil, true},
+		{[]string{"0xAbc", "--format"}, "", nil, false},
+		{[]string{"--format=xml"}, "", nil, false},
+	} {
+		format, rest, ok := takeFormat(tc.words)
+		if format != tc.format || !reflect.DeepEqual(rest, tc.rest) || ok != tc.ok {
+			t.Errorf("takeFormat(%q) = %q, %q, %v; want %q, %q, %v", tc.words, format, rest, ok, tc.format, tc.rest, tc.ok)
+		}
+	}
+}
+
+func TestHelpLine(t *testing.T) {
+	for name, want := range map[string]string{
+		"save":     "save <filename> - Save the game state to a file (** RESTRICTED: gamemaster **)",
+		"load":     "load <filename> - Load the game state from a file (** RESTRICTED: admin **)",
+		"transfer": "transfer <walletAddress> <game|art|tech> <amount> - Send some of your tokens to another player (** RESTRICTED: player **)",
+		"list":     "list [--format json|csv|table] - List all active players",
+	} {
+		c, _ := lookup(name)
+		if got := c.helpLine(palette{}); got != want {
+			t.Errorf("help for %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	c := completer{&shell{g: game.New()}}
+	for line, want := range map[string][]string{
+		"tr":                 {"ansfer "},
+		"lea":                {"derboard "},
+		"transfer 0xT":       {"ippi "},
+		"transfer 0xTippi a": {"rt "},
+		"read Cryo":          {"-Mountain "},
+		"offer 3 art f":      {"or "},
+		"riddle so":          {"lidity ", "lidity-view ", "lidity-tx-origin "},
+		"riddle go-d":        {"efer "},
+		"hint react-":        {"key ", "effect "},
+		"help histo":         {"ry "},
+		"check 0xTippi --f":  {"ormat "},
+		"list --format c":    {"sv "},
+		"list --format ":     {"json ", "csv ", "table "},
+		"whoami x":           nil,
+		"nonsense 0x":        nil,
+	} {
+		got, _ := c.Do([]rune(line), len([]rune(line)))
+		var words []string
+		for _, rest := range got {
+			words = append(words, string(rest))
+		}
+		if !reflect.DeepEqual(words, want) {
+			t.Errorf("complete %q = %q, want %q", line, words, want)
+		}
+	}
+}
+
+func TestRunScript(t *testing.T) {
+	const script = `# Set up the table
+add 0xAda "Ada Lovelace"
+
+award 0xAda 1 0 0 0 0 0 "first session"
+transfer 0xNobody game 1
+add 0xBob Bob
+`
+	for _, dryRun := range []bool{false, true} {
+		g := game.New()
+		err := RunScript(g, game.NewChallengeStore(), strings.NewReader(script), "setup.txt", BatchOptions{As: "0xTippi", DryRun: dryRun})
+		if !errors.Is(err, ErrCommandFailed) || !strings.HasPrefix(err.Error(), "setup.txt:5: ") {
+			t.Errorf("dry run %v: err = %v, want a failure on line 5", dryRun, err)
+		}
+		_, added := g.Player("0xAda")
+		if added == dryRun {
+			t.Errorf("dry run %v: 0xAda added = %v", dryRun, added)
+		}
+		if _, added := g.Player("0xBob"); added {
+			t.Errorf("dry run %v: the script went on after the failure", dryRun)
+		}
+	}
+
+	err := RunScript(game.New(), game.NewChallengeStore(), strings.NewReader("list\n"), "stdin", BatchOptions{As: "0xNobody"})
+	if err == nil || errors.Is(err, ErrCommandFailed) {
+		t.Errorf("running as an unknown wallet: err = %v", err)
+	}
+}
+
+func TestRiddleAfterBankShrinks(t *testing.T) {
+	g := game.New()
+	for range 2 {
+		if _, err := g.RiddleHint("0xTippi", "go"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "riddles.yaml")
+	bank := `riddles: [{id: go, language: go, prompt: "Declare and assign?", answer: {accept: [":="]}, hints: [Two characters.]}]`
+	if err := os.WriteFile(filename, []byte(bank), 0644); err != nil {
+		t.Fatal(err)
+	}
+	smaller, err := game.LoadRiddleBank(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetRiddleBank(smaller)
+
+	if err := RunScript(g, game.NewChallengeStore(), strings.NewReader("riddle go\n:=\n"), "stdin", BatchOptions{As: "0xTippi"}); err != nil {
+		t.Fatal(err)
+	}
+	if attempt := g.RiddleAttempt("0xTippi", "go"); !attempt.Solved {
+		t.Errorf("attempt = %+v, want solved", attempt)
+	}
+}
@@ -0,0 +1,235 @@
+// Description: This file contains the terminal handling for the REPL: line editing, history and tab completion on a real terminal, a plain line reader otherwise, and colors only when the output is a terminal.
+
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// lineReader is where the prompt gets its input: a line editor with
+// history and completion on a terminal, or plain lines otherwise.
+type lineReader interface {
+	// ReadLine shows the prompt and returns the next line without its
+	// newline. It returns io.EOF at the end of input.
+	ReadLine(prompt string) (string, error)
+	// Remember adds a command line to the history.
+	Remember(line string)
+	Close() error
+}
+
+// plainReader reads lines from any reader, such as a pipe, a script or a test.
+type plainReader struct {
+	buf   *bufio.Reader
+	quiet bool // Don't print prompts
+	lines int  // Lines read so far
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	if !r.quiet {
+		fmt.Print(prompt)
+	}
+	line, err := r.buf.ReadString('\n')
+	if line != "" {
+		r.lines++
+	}
+	if err == io.EOF && line != "" {
+		err = nil // The last line had no newline
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (r *plainReader) Remember(line string) {}
+
+func (r *plainReader) Close() error { return nil }
+
+// editor is the interactive line editor.
+type editor struct {
+	rl *readline.Instance
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	e.rl.SetPrompt(prompt)
+	for {
+		line, err := e.rl.Readline()
+		if err == readline.ErrInterrupt {
+			if line == "" {
+				return "", io.EOF // Ctrl-C on an empty line leaves, like Ctrl-D
+			}
+			continue // Ctrl-C throws away what was typed
+		}
+		return line, err
+	}
+}
+
+func (e *editor) Remember(line string) {
+	e.rl.SaveHistory(line)
+}
+
+func (e *editor) Close() error { return e.rl.Close() }
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	return readline.IsTerminal(int(f.Fd()))
+}
+
+// newLineReader uses the line editor when in and stdout are both terminals.
+// Only command lines go into the history, not signatures or riddle answers.
+func newLineReader(in io.Reader, historyFile string, complete readline.AutoCompleter) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(f) && isTerminal(os.Stdout) {
+		rl, err := readline.NewEx(&readline.Config{
+			Stdin:                  f,
+			HistoryFile:            historyFile,
+			DisableAutoSaveHistory: true,
+			AutoComplete:           complete,
+		})
+		if err == nil {
+			return &editor{rl: rl}
+		}
+	}
+	return &plainReader{buf: bufio.NewReader(in)}
+}
+
+// palette colors output with ANSI escapes, or leaves it plain when
+// disabled.
+type palette struct {
+	enabled bool
+}
+
+// newPalette colors output only on a terminal, and never when NO_COLOR is set.
+func newPalette() palette {
+	return palette{enabled: isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == ""}
+}
+
+func (p palette) paint(code, s string) string {
+	if !p.enabled {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+func (p palette) red(s string) string    { return p.paint("31", s) }
+func (p palette) yellow(s string) string { return p.paint("33", s) }
+func (p palette) green(s string) string  { return p.paint("32", s) }
+func (p palette) dim(s string) string    { return p.paint("2", s) }
+
+// completer offers command names, then whatever the argument under the
+// cursor expects: wallet addresses, locations, riddle IDs, or one of the options of a
+// <a|b|c> argument. Commands that take --format also complete it and its
+// values.
+type completer struct {
+	s *shell
+}
+
+func (c completer) Do(line []rune, pos int) ([][]rune, int) {
+	typed := string(line[:pos])
+	words := strings.Fields(typed)
+	if len(words) == 0 {
+		return suffixes(commandNames(), "")
+	}
+	if len(words) == 1 && !strings.HasSuffix(typed, " ") {
+		return suffixes(commandNames(), words[0])
+	}
+	cmd, ok := lookup(words[0])
+	if !ok {
+		return nil, 0
+	}
+	index := len(words) - 1 // Of the argument being typed
+	word := words[len(words)-1]
+	if strings.HasSuffix(typed, " ") {
+		index, word = len(words), ""
+	}
+	if cmd.format {
+		switch {
+		case words[len(words)-1] == "--format" && strings.HasSuffix(typed, " "):
+			return suffixes(outputFormats, "")
+		case len(words) > 2 && words[len(words)-2] == "--format" && !strings.HasSuffix(typed, " "):
+			return suffixes(outputFormats, word)
+		case strings.HasPrefix(word, "-"):
+			return suffixes([]string{"--format"}, word)
+		}
+	}
+	index-- // Skip the command name
+	if index >= len(cmd.params) {
+		return nil, 0
+	}
+	p := cmd.params[index]
+	if p.rest {
+		// Rest arguments may hold spaces, so complete all of it.
+		word = afterWords(typed, index+1)
+	}
+	return suffixes(c.candidates(p), word)
+}
+
+// candidates lists what can be typed for the parameter.
+func (c completer) candidates(p param) []string {
+	switch {
+	case p.literal:
+		return []string{p.name}
+	case strings.Contains(p.name, "|"):
+		return strings.Split(p.name, "|")
+	case strings.HasPrefix(p.name, "walletAddress"):
+		var wallets []string
+		for _, player := range c.s.g.ListPlayers() {
+			wallets = append(wallets, player.WalletAddress)
+		}
+		for _, player := range c.s.g.PurgatoryPlayers() {
+			wallets = append(wallets, player.WalletAddress)
+		}
+		return wallets
+	case strings.HasPrefix(p.name, "locationName"):
+		return locationNames()
+	case strings.HasPrefix(p.name, "topic"):
+		bank := c.s.g.RiddleBank()
+		topics := bank.Topics()
+		for _, id := range bank.IDs() {
+			if !slices.Contains(topics, id) {
+				topics = append(topics, id)
+			}
+		}
+		return topics
+	case p.name == "command":
+		return commandNames()
+	}
+	return nil
+}
+
+// suffixes returns the rest of each candidate that starts with word, in
+// the form readline wants.
+func suffixes(candidates []string, word string) ([][]rune, int) {
+	var rest [][]rune
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			rest = append(rest, []rune(candidate[len(word):]+" "))
+		}
+	}
+	return rest, len([]rune(word))
+}
+
+func commandNames() []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+		names = append(names, c.aliases...)
+	}
+	return names
+}
+
+// afterWords drops the first n words of s and the spaces around them.
+func afterWords(s string, n int) string {
+	for i := 0; i < n; i++ {
+		s = strings.TrimLeft(s, " ")
+		end := strings.IndexByte(s, ' ')
+		if end < 0 {
+			return ""
+		}
+		s = s[end:]
+	}
+	return strings.TrimLeft(s, " ")
+}
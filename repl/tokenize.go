@@ -0,0 +1,66 @@
+// Description: This file contains the line tokenizer for the REPL. It splits a command line into words, keeping quoted strings together and honouring backslash escapes, so player names and reasons can contain spaces.
+
+package repl
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errUnterminatedQuote = errors.New("unterminated quote")
+	errTrailingBackslash = errors.New("nothing to escape after the final backslash")
+)
+
+// tokenize splits a command line into words the way a shell does: runs of
+// whitespace separate words, 'single quotes' keep everything literally,
+// "double quotes" keep spaces but still honour \" and \\, and a backslash
+// outside quotes escapes the next character. "" is an empty word.
+func tokenize(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool // Set by quotes too, so "" still makes a word
+		quote   rune // The open quote, or 0
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				word.WriteRune('\\') // Inside double quotes only \" and \\ are escapes
+			}
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped {
+		return nil, errTrailingBackslash
+	}
+	if quote != 0 {
+		return nil, errUnterminatedQuote
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
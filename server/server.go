@@ -0,0 +1,851 @@
+// Package server is the HTTP game server: a JSON API that mirrors the REPL
+// commands and enforces the same rules. Errors come back as
+// {"Error": ..., "Message": ...} with a matching status code. JSON fields,
+// in requests and responses alike, are named like the Go fields, as in saves.
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tippi-fifestarr/go-ceptor/content"
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// openAPISpec is the OpenAPI 3 description of this server, served at /openapi.json.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// apiError is the JSON body of every error response.
+type apiError struct {
+	Error   string // Machine-readable code, e.g. "not_found"
+	Message string // Human-readable explanation
+}
+
+type loginResponse struct {
+	Token         string
+	WalletAddress string
+	Role          game.Role
+}
+
+// statusResponse is the logged in player plus their levels.
+type statusResponse struct {
+	game.Player
+	Levels game.PlayerLevels
+}
+
+type addPlayerRequest struct {
+	WalletAddress string
+	PlayerName    string
+}
+
+type awardRequest struct {
+	GameTokens int
+	ArtTokens  int
+	TechTokens int
+	ArtXP      int
+	GameXP     int
+	TechXP     int
+	Reason     string // Required when any amount is negative
+}
+
+type transferRequest struct {
+	To     string
+	Token  game.Token
+	Amount int
+}
+
+type offerRequest struct {
+	Give       game.Token
+	GiveAmount int
+	Want       game.Token
+	WantAmount int
+}
+
+// leaderboardPage is one page of a leaderboard.
+type leaderboardPage struct {
+	Metric   game.Metric
+	Period   game.Period
+	Page     int
+	PageSize int
+	Total    int // Players on the whole leaderboard
+	Rows     []game.LeaderboardRow
+}
+
+type appealRequest struct {
+	Appeal string
+}
+
+type roleRequest struct {
+	Role game.Role
+}
+
+type fileRequest struct {
+	Filename string
+}
+
+// riddleView is a riddle as the session player sees it. The answer key
+// never leaves the server, and only the hints they have bought are shown.
+type riddleView struct {
+	ID           string
+	Language     string
+	Difficulty   game.Difficulty
+	Prompt       string
+	Code         string
+	CodeAnswer   bool // Answered with Go code, run against hidden tests
+	Attempted    bool
+	Solved       bool
+	Locked       bool // Solved or out of attempts
+	Tries        int
+	MaxAttempts  int           // 0 means unlimited
+	AttemptsLeft int           // -1 means unlimited
+	RetryAt      *time.Time    `json:",omitempty"` // Set during the cooldown after a wrong answer
+	Reward       game.Balances // What a right answer pays now
+	Hints        []string      // Bought so far
+	HintsLeft    int
+	HintCost     game.Balances
+}
+
+func newRiddleView(riddle game.Riddle, attempt game.RiddleAttempt) riddleView {
+	view := riddleView{
+		ID:           riddle.ID,
+		Language:     riddle.Language,
+		Difficulty:   riddle.Difficulty,
+		Prompt:       riddle.Prompt,
+		Code:         riddle.Code,
+		CodeAnswer:   riddle.Answer.Match == game.MatchGo,
+		Attempted:    attempt.Tries > 0,
+		Solved:       attempt.Solved,
+		Locked:       riddle.Locked(attempt),
+		Tries:        attempt.Tries,
+		MaxAttempts:  riddle.MaxAttempts,
+		AttemptsLeft: riddle.AttemptsLeft(attempt),
+		Hints:        append([]string{}, riddle.RevealedHints(attempt)...),
+		HintsLeft:    len(riddle.Hints) - len(riddle.RevealedHints(attempt)),
+		HintCost:     riddle.HintCost,
+	}
+	if !view.Locked {
+		view.Reward = riddle.PayoutAfter(attempt.Tries)
+	}
+	if retryAt := riddle.RetryAt(attempt); time.Now().Before(retryAt) {
+		view.RetryAt = &retryAt
+	}
+	return view
+}
+
+type answerRequest struct {
+	Answer string
+}
+
+type hintResponse struct {
+	ID    string
+	Hints []string // Every hint bought so far, the new one last
+}
+
+const sessionCookieName = "ceptor_session"
+
+// NewMux wires up the HTTP handlers for the game server.
+func NewMux(g *game.Game, sessions *game.SessionStore, challenges *game.ChallengeStore) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", handleRoot)
+	mux.HandleFunc("GET /openapi.json", handleOpenAPI)
+	mux.HandleFunc("GET /login/nonce", handleNonce(challenges))
+	mux.HandleFunc("POST /login", handleLogin(g, sessions, challenges))
+	mux.HandleFunc("POST /logout", handleLogout(sessions))
+	mux.HandleFunc("GET /status", requireSession(sessions, handleStatus(g)))
+
+	mux.HandleFunc("GET /players", handleListPlayers(g))
+	mux.HandleFunc("POST /players", requirePermission(g, sessions, game.PermAddPlayer, handleAddPlayer(g)))
+	mux.HandleFunc("GET /players/{wallet}", handleCheckPlayer(g))
+	mux.HandleFunc("DELETE /players/{wallet}", requirePermission(g, sessions, game.PermRemovePlayer, handleRemovePlayer(g)))
+	mux.HandleFunc("POST /players/{wallet}/awards", requirePermission(g, sessions, game.PermAward, handleAward(g)))
+	mux.HandleFunc("GET /players/{wallet}/ledger", handleLedger(g))
+	mux.HandleFunc("PUT /players/{wallet}/role", requirePermission(g, sessions, game.PermManageRoles, handleSetRole(g)))
+	mux.HandleFunc("GET /allowlist", handleAllowList(g))
+	mux.HandleFunc("GET /purgatory", requirePermission(g, sessions, game.PermRemovePlayer, handlePurgatory(g)))
+	mux.HandleFunc("POST /purgatory/{wallet}/restore", requirePermission(g, sessions, game.PermRemovePlayer, handleRestorePlayer(g)))
+	mux.HandleFunc("PUT /purgatory/{wallet}/appeal", requirePermission(g, sessions, game.PermRemovePlayer, handleAppeal(g)))
+	mux.HandleFunc("GET /leaderboard", handleLeaderboard(g))
+
+	mux.HandleFunc("POST /transfers", requirePermission(g, sessions, game.PermTrade, handleTransfer(g)))
+	mux.HandleFunc("GET /offers", handleOffers(g))
+	mux.HandleFunc("POST /offers", requirePermission(g, sessions, game.PermTrade, handlePostOffer(g)))
+	mux.HandleFunc("POST /offers/{id}/accept", requirePermission(g, sessions, game.PermTrade, handleAcceptOffer(g)))
+	mux.HandleFunc("DELETE /offers/{id}", requirePermission(g, sessions, game.PermTrade, handleCancelOffer(g)))
+
+	mux.HandleFunc("POST /save", requirePermission(g, sessions, game.PermSaveGame, handleSave(g)))
+	mux.HandleFunc("POST /load", requirePermission(g, sessions, game.PermLoadGame, handleLoad(g)))
+
+	mux.HandleFunc("GET /locations", handleLocations)
+	mux.HandleFunc("GET /locations/{name}", handleReadLocation(g, sessions))
+	mux.HandleFunc("GET /adventure", handleAdventure)
+	mux.HandleFunc("GET /characters", handleCharacters)
+
+	mux.HandleFunc("GET /achievements", handleAchievements)
+	mux.HandleFunc("GET /notifications", requireSession(sessions, handleNotifications(g)))
+
+	mux.HandleFunc("GET /riddles", requireSession(sessions, handleRiddles(g)))
+	mux.HandleFunc("GET /riddles/{id}", requireSession(sessions, handleRiddle(g)))
+	mux.HandleFunc("POST /riddles/{id}/answer", requireSession(sessions, handleAnswerRiddle(g)))
+	mux.HandleFunc("POST /riddles/{id}/hint", requireSession(sessions, handleRiddleHint(g)))
+	return mux
+}
+
+// ListenAndServe runs handler on addr until ctx is cancelled, then shuts
+// the server down gracefully.
+func ListenAndServe(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+	errc := make(chan error, 1)
+	go func() {
+		log.Println("Starting server on", addr)
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// sessionFromRequest works out the caller's session from the session cookie
+// or an "Authorization: Bearer <token>" header.
+func sessionFromRequest(sessions *game.SessionStore, r *http.Request) (*game.Session, bool) {
+	token := ""
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		token = cookie.Value
+	} else if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if token == "" {
+		return nil, false
+	}
+	return sessions.Get(token)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiError{Error: code, Message: message})
+}
+
+// writeGameError maps the game's sentinel errors onto status codes.
+func writeGameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, game.ErrPlayerNotFound), errors.Is(err, game.ErrUnknownRiddle), errors.Is(err, game.ErrOfferNotFound),
+		errors.Is(err, game.ErrUnknownLocation), errors.Is(err, game.ErrNotInPurgatory):
+		writeError(w, http.StatusNotFound, "not_found", err.Error())
+	case errors.Is(err, game.ErrPlayerExists), errors.Is(err, game.ErrRiddleAttempted), errors.Is(err, game.ErrLastAdmin),
+		errors.Is(err, game.ErrInsufficientBalance), errors.Is(err, game.ErrOfferClosed),
+		errors.Is(err, game.ErrTopicExhausted), errors.Is(err, game.ErrNoMoreHints), errors.Is(err, game.ErrInPurgatory),
+		errors.Is(err, game.ErrSaveOverStore):
+		writeError(w, http.StatusConflict, "conflict", err.Error())
+	case errors.Is(err, game.ErrRiddleCooldown):
+		writeError(w, http.StatusTooManyRequests, "cooldown", err.Error())
+	case errors.Is(err, game.ErrUnknownRole), errors.Is(err, game.ErrReasonRequired),
+		errors.Is(err, game.ErrUnknownToken), errors.Is(err, game.ErrInvalidAmount),
+		errors.Is(err, game.ErrSelfTrade), errors.Is(err, game.ErrSameTokenOffer):
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+	case errors.Is(err, game.ErrNotOfferSeller), errors.Is(err, game.ErrPermissionDenied):
+		writeError(w, http.StatusForbidden, "forbidden", err.Error())
+	default:
+		// The details are for the logs, not for players.
+		log.Println("internal error:", err)
+		writeError(w, http.StatusInternalServerError, "internal", "something went wrong on the server")
+	}
+}
+
+// maxBodyBytes caps JSON request bodies. The largest is a code answer,
+// which goes on to be built and run.
+const maxBodyBytes = 64 << 10
+
+// decodeJSON reads the request body into v, answering 400 or 413 itself when
+// it can't.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "too_large", fmt.Sprintf("request body is over %d bytes", tooLarge.Limit))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+type sessionHandler func(w http.ResponseWriter, r *http.Request, session *game.Session)
+
+// requireSession only runs the handler for logged in callers.
+func requireSession(sessions *game.SessionStore, next sessionHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := sessionFromRequest(sessions, r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "unauthorized", "no user logged in")
+			return
+		}
+		next(w, r, session)
+	}
+}
+
+// requirePermission only runs the handler for sessions that hold the permission.
+func requirePermission(g *game.Game, sessions *game.SessionStore, p game.Permission, next sessionHandler) http.HandlerFunc {
+	return requireSession(sessions, func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		if !g.Can(session, p) {
+			writeError(w, http.StatusForbidden, "forbidden", game.ErrPermissionDenied.Error())
+			return
+		}
+		next(w, r, session)
+	})
+}
+
+func handleRoot(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"Message": "Welcome to the Ceptor Club Game Server"})
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func handleNonce(challenges *game.ChallengeStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		message, err := challenges.Issue(r.URL.Query().Get("wallet"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"Message": message})
+	}
+}
+
+func handleLogin(g *game.Game, sessions *game.SessionStore, challenges *game.ChallengeStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		walletAddress := r.FormValue("wallet") // Use FormValue to read POST form data
+		if err := challenges.VerifyLogin(walletAddress, r.FormValue("signature")); err != nil {
+			writeError(w, http.StatusUnauthorized, "unauthorized", "login failed: "+err.Error())
+			return
+		}
+		if !g.Login(walletAddress) {
+			writeError(w, http.StatusUnauthorized, "unauthorized", "login failed: wallet is not on the allow list")
+			return
+		}
+		session, err := sessions.Create(walletAddress)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal", "could not start session")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookieName,
+			Value:    session.Token,
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		w.Header().Set("X-Session-Token", session.Token) // For clients that send "Authorization: Bearer <token>" instead of cookies
+		writeJSON(w, http.StatusOK, loginResponse{Token: session.Token, WalletAddress: walletAddress, Role: g.RoleOf(walletAddress)})
+	}
+}
+
+func handleLogout(sessions *game.SessionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if session, ok := sessionFromRequest(sessions, r); ok {
+			sessions.Delete(session.Token)
+		}
+		http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: "", Path: "/", MaxAge: -1})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleStatus(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		currentPlayer, ok := g.Player(session.WalletAddress)
+		if !ok {
+			writeGameError(w, game.ErrPlayerNotFound)
+			return
+		}
+		levels, _ := g.Levels(session.WalletAddress)
+		writeJSON(w, http.StatusOK, statusResponse{Player: currentPlayer, Levels: levels})
+	}
+}
+
+func handleListPlayers(g *game.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		players := g.ListPlayers()
+		sort.Slice(players, func(i, j int) bool { return players[i].WalletAddress < players[j].WalletAddress })
+		writeJSON(w, http.StatusOK, players)
+	}
+}
+
+func handleAddPlayer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req addPlayerRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.WalletAddress == "" || req.PlayerName == "" {
+			writeError(w, http.StatusBadRequest, "bad_request", "wallet and name are required")
+			return
+		}
+		if err := g.AddPlayer(req.WalletAddress, req.PlayerName); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		player, _ := g.Player(req.WalletAddress)
+		writeJSON(w, http.StatusCreated, player)
+	}
+}
+
+func handleCheckPlayer(g *game.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		player, ok := g.Player(r.PathValue("wallet"))
+		if !ok {
+			writeGameError(w, game.ErrPlayerNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, player)
+	}
+}
+
+func handleRemovePlayer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		if err := g.RemovePlayer(session.WalletAddress, r.PathValue("wallet"), r.URL.Query().Get("reason")); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handlePurgatory(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		writeJSON(w, http.StatusOK, g.PurgatoryPlayers())
+	}
+}
+
+func handleRestorePlayer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		walletAddress := r.PathValue("wallet")
+		if err := g.RestorePlayer(walletAddress); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		player, _ := g.Player(walletAddress)
+		writeJSON(w, http.StatusOK, player)
+	}
+}
+
+func handleAppeal(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req appealRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Appeal == "" {
+			writeError(w, http.StatusBadRequest, "bad_request", "appeal is required")
+			return
+		}
+		if err := g.AppealRemoval(r.PathValue("wallet"), req.Appeal); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleAward(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req awardRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		walletAddress := r.PathValue("wallet")
+		delta := game.Balances{GameTokens: req.GameTokens, ArtTokens: req.ArtTokens, TechTokens: req.TechTokens, ArtXP: req.ArtXP, GameXP: req.GameXP, TechXP: req.TechXP}
+		if err := g.AwardTokensXP(session.WalletAddress, walletAddress, delta, req.Reason); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		player, _ := g.Player(walletAddress)
+		writeJSON(w, http.StatusOK, player)
+	}
+}
+
+func handleLedger(g *game.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		walletAddress := r.PathValue("wallet")
+		entries := g.History(walletAddress)
+		if entries == nil {
+			if _, ok := g.Player(walletAddress); !ok {
+				writeGameError(w, game.ErrPlayerNotFound)
+				return
+			}
+			entries = []game.LedgerEntry{}
+		}
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// handleLeaderboard serves ?metric=&period=&page=&pageSize=. Pages start at 1.
+func handleLeaderboard(g *game.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		metric, err := game.ParseMetric(query.Get("metric"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		period, err := game.ParsePeriod(query.Get("period"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		page, ok := queryInt(w, query.Get("page"), "page", 1, 1, 0)
+		if !ok {
+			return
+		}
+		pageSize, ok := queryInt(w, query.Get("pageSize"), "pageSize", defaultPageSize, 1, maxPageSize)
+		if !ok {
+			return
+		}
+
+		rows := g.Leaderboard(metric, period)
+		start := min((page-1)*pageSize, len(rows))
+		end := min(start+pageSize, len(rows))
+		writeJSON(w, http.StatusOK, leaderboardPage{
+			Metric:   metric,
+			Period:   period,
+			Page:     page,
+			PageSize: pageSize,
+			Total:    len(rows),
+			Rows:     rows[start:end],
+		})
+	}
+}
+
+// queryInt parses an optional whole-number query parameter between lo and
+// hi (no upper limit when hi is 0), answering 400 itself when it can't.
+func queryInt(w http.ResponseWriter, value, name string, fallback, lo, hi int) (int, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < lo || (hi > 0 && n > hi) {
+		message := fmt.Sprintf("%s must be a whole number of at least %d", name, lo)
+		if hi > 0 {
+			message = fmt.Sprintf("%s must be a whole number from %d to %d", name, lo, hi)
+		}
+		writeError(w, http.StatusBadRequest, "bad_request", message)
+		return 0, false
+	}
+	return n, true
+}
+
+func handleTransfer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req transferRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		token, err := game.ParseToken(string(req.Token))
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		if err := g.Transfer(session.WalletAddress, req.To, token, req.Amount); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		player, _ := g.Player(session.WalletAddress)
+		writeJSON(w, http.StatusOK, player)
+	}
+}
+
+func handleOffers(g *game.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offers := g.OpenOffers()
+		if offers == nil {
+			offers = []game.Offer{}
+		}
+		writeJSON(w, http.StatusOK, offers)
+	}
+}
+
+func handlePostOffer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req offerRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		give, err := game.ParseToken(string(req.Give))
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		want, err := game.ParseToken(string(req.Want))
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		offer, err := g.PostOffer(session.WalletAddress, give, req.GiveAmount, want, req.WantAmount)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, offer)
+	}
+}
+
+func handleAcceptOffer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeGameError(w, game.ErrOfferNotFound)
+			return
+		}
+		offer, err := g.AcceptOffer(session.WalletAddress, id)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, offer)
+	}
+}
+
+func handleCancelOffer(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			writeGameError(w, game.ErrOfferNotFound)
+			return
+		}
+		offer, err := g.CancelOffer(session.WalletAddress, id)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, offer)
+	}
+}
+
+func handleSetRole(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req roleRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		walletAddress := r.PathValue("wallet")
+		if err := g.SetRole(walletAddress, req.Role); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		player, _ := g.Player(walletAddress)
+		writeJSON(w, http.StatusOK, player)
+	}
+}
+
+func handleAllowList(g *game.Game) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wallets := g.AllowedWallets()
+		sort.Strings(wallets)
+		writeJSON(w, http.StatusOK, wallets)
+	}
+}
+
+// saveDir is where the API saves and loads games, away from the source tree
+// and the store.
+var saveDir = "saves"
+
+// saveFilename only accepts plain file names, and returns the name's path in
+// saveDir, so the API can't read or write anywhere else.
+func saveFilename(w http.ResponseWriter, r *http.Request) (name, path string, ok bool) {
+	var req fileRequest
+	if !decodeJSON(w, r, &req) {
+		return "", "", false
+	}
+	if req.Filename == "" || req.Filename != filepath.Base(req.Filename) || strings.HasPrefix(req.Filename, ".") {
+		writeError(w, http.StatusBadRequest, "bad_request", "filename must be a plain file name")
+		return "", "", false
+	}
+	return req.Filename, filepath.Join(saveDir, req.Filename), true
+}
+
+func handleSave(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		filename, path, ok := saveFilename(w, r)
+		if !ok {
+			return
+		}
+		if err := os.MkdirAll(saveDir, 0755); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		if err := g.Save(path); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, fileRequest{Filename: filename})
+	}
+}
+
+func handleLoad(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		filename, path, ok := saveFilename(w, r)
+		if !ok {
+			return
+		}
+		loadedGame, err := game.Load(path)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "error loading game: "+err.Error())
+			return
+		}
+		if err := g.Replace(loadedGame); err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, fileRequest{Filename: filename})
+	}
+}
+
+func handleLocations(w http.ResponseWriter, r *http.Request) {
+	locations := make([]content.Location, 0, len(content.Locations))
+	for _, loc := range content.Locations {
+		locations = append(locations, loc)
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].Name < locations[j].Name })
+	writeJSON(w, http.StatusOK, locations)
+}
+
+// handleReadLocation is open to everyone; logged in players also get the
+// visit counted towards their badges.
+func handleReadLocation(g *game.Game, sessions *game.SessionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc, ok := content.Locations[r.PathValue("name")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "not_found", "location not found")
+			return
+		}
+		if session, ok := sessionFromRequest(sessions, r); ok {
+			g.VisitLocation(session.WalletAddress, loc.Name)
+		}
+		writeJSON(w, http.StatusOK, loc)
+	}
+}
+
+func handleAchievements(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, game.Achievements)
+}
+
+// handleNotifications hands over the player's unread notifications, such as
+// unlocked badges, and marks them read.
+func handleNotifications(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		notifications, err := g.TakeNotifications(session.WalletAddress)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		if notifications == nil {
+			notifications = []string{}
+		}
+		writeJSON(w, http.StatusOK, notifications)
+	}
+}
+
+func handleAdventure(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, content.MainAdventure)
+}
+
+func handleCharacters(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, content.PregeneratedCharacters())
+}
+
+// handleRiddles lists the riddles the session player can still answer,
+// easiest first within each topic.
+func handleRiddles(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		if _, ok := g.Player(session.WalletAddress); !ok {
+			writeGameError(w, game.ErrPlayerNotFound)
+			return
+		}
+		bank := g.RiddleBank()
+		riddles := make([]riddleView, 0, len(bank.Riddles))
+		for _, topic := range bank.Topics() {
+			for _, riddle := range bank.Topic(topic) {
+				view := newRiddleView(riddle, g.RiddleAttempt(session.WalletAddress, riddle.ID))
+				if !view.Locked {
+					riddles = append(riddles, view)
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, riddles)
+	}
+}
+
+// handleRiddle shows one riddle, including ones the session player has
+// solved or run out of attempts on.
+func handleRiddle(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		if _, ok := g.Player(session.WalletAddress); !ok {
+			writeGameError(w, game.ErrPlayerNotFound)
+			return
+		}
+		riddle, ok := g.RiddleBank().Riddle(r.PathValue("id"))
+		if !ok {
+			writeGameError(w, game.ErrUnknownRiddle)
+			return
+		}
+		writeJSON(w, http.StatusOK, newRiddleView(riddle, g.RiddleAttempt(session.WalletAddress, riddle.ID)))
+	}
+}
+
+func handleAnswerRiddle(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		var req answerRequest
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		result, err := g.AnswerRiddle(session.WalletAddress, r.PathValue("id"), req.Answer)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+func handleRiddleHint(g *game.Game) sessionHandler {
+	return func(w http.ResponseWriter, r *http.Request, session *game.Session) {
+		id := r.PathValue("id")
+		hints, err := g.RiddleHint(session.WalletAddress, id)
+		if err != nil {
+			writeGameError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, hintResponse{ID: id, Hints: hints})
+	}
+}
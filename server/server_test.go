@@ -0,0 +1,443 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// newTestWallet returns a locally generated wallet address and a personal_sign signer for it.
+func newTestWallet(t *testing.T) (string, func(message string) string) {
+	t.Helper()
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := "0x" + hex.EncodeToString(keccak256(key.PubKey().SerializeUncompressed()[1:])[12:])
+	sign := func(message string) string {
+		hash := keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), []byte(message))
+		compact := ecdsa.SignCompact(key, hash, false)
+		return "0x" + hex.EncodeToString(append(compact[1:], compact[0]))
+	}
+	return address, sign
+}
+
+func TestLoginWithSignature(t *testing.T) {
+	wallet, sign := newTestWallet(t)
+	g := game.New()
+	g.AddPlayer(wallet, "Tester")
+	sessions := game.NewSessionStore()
+	mux := NewMux(g, sessions, game.NewChallengeStore())
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/login/nonce?wallet="+wallet, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("nonce: status %d: %s", rec.Code, rec.Body)
+	}
+	var nonce struct{ Message string }
+	if err := json.NewDecoder(rec.Body).Decode(&nonce); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(signature string) *httptest.ResponseRecorder {
+		form := url.Values{"wallet": {wallet}, "signature": {signature}}
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec = login(sign(nonce.Message))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status %d: %s", rec.Code, rec.Body)
+	}
+	var resp loginResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	session, ok := sessions.Get(resp.Token)
+	if !ok || session.WalletAddress != wallet {
+		t.Fatalf("login did not create a session for %s", wallet)
+	}
+
+	// The nonce was used up, so the same signature cannot log in again.
+	if rec := login(sign(nonce.Message)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("replayed login: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestLeaderboardPages(t *testing.T) {
+	g := game.New()
+	for i := 1; i <= 3; i++ {
+		walletAddress := fmt.Sprintf("0xPlayer%d", i)
+		g.AddPlayer(walletAddress, walletAddress)
+		g.AwardTokensXP(game.SystemActor, walletAddress, game.Balances{ArtXP: 10 * i}, "")
+	}
+	mux := NewMux(g, game.NewSessionStore(), game.NewChallengeStore())
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/leaderboard?"+query, nil))
+		return rec
+	}
+
+	rec := get("metric=artxp&page=1&pageSize=2")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var page leaderboardPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	// Tippi's 100 Art XP leads the three new players.
+	if page.Total != 4 || len(page.Rows) != 2 || page.Rows[0].WalletAddress != "0xTippi" || page.Rows[1].WalletAddress != "0xPlayer3" {
+		t.Errorf("page 1 = %+v", page)
+	}
+
+	rec = get("metric=artxp&page=2&pageSize=2")
+	page = leaderboardPage{}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Rows) != 2 || page.Rows[1].Rank != 4 {
+		t.Errorf("page 2 = %+v", page)
+	}
+
+	for _, query := range []string{"metric=karma", "metric=artxp&period=monthly", "metric=artxp&page=0", "metric=artxp&pageSize=1000"} {
+		if rec := get(query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestRiddles(t *testing.T) {
+	g := game.New()
+	g.AddPlayer("0xNew", "Newcomer")
+	sessions := game.NewSessionStore()
+	session, err := sessions.Create("0xNew")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewMux(g, sessions, game.NewChallengeStore())
+
+	do := func(method, path, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		for _, secret := range []string{"accept", "210", "Deferred calls run", "TestTally"} {
+			if method == "GET" && strings.Contains(rec.Body.String(), secret) {
+				t.Errorf("%s %s leaks %q: %s", method, path, secret, rec.Body)
+			}
+		}
+		if out != nil && rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code
+	}
+
+	var riddles []riddleView
+	if code := do("GET", "/riddles", "", &riddles); code != http.StatusOK || len(riddles) != len(g.RiddleBank().Riddles) {
+		t.Fatalf("GET /riddles: status %d, %d riddles", code, len(riddles))
+	}
+
+	var result game.RiddleResult
+	if code := do("POST", "/riddles/go-defer/answer", `{"Answer": "210"}`, &result); code != http.StatusOK {
+		t.Fatalf("right answer: status %d", code)
+	}
+	if want := (game.Balances{GameXP: 10, TechXP: 10}); !result.Correct || !result.Locked || result.AttemptsLeft != 0 || result.Paid != want {
+		t.Errorf("right answer = %+v, want %+v paid", result, want)
+	}
+	if code := do("POST", "/riddles/go-defer/answer", `{"Answer": "210"}`, nil); code != http.StatusConflict {
+		t.Errorf("answering a solved riddle: status %d, want %d", code, http.StatusConflict)
+	}
+
+	if code := do("POST", "/riddles/go/answer", `{"Answer": "="}`, &result); code != http.StatusOK {
+		t.Fatalf("wrong answer: status %d", code)
+	}
+	if result.Correct || result.Locked || result.Tries != 1 || result.AttemptsLeft != 2 {
+		t.Errorf("wrong answer = %+v", result)
+	}
+	if code := do("POST", "/riddles/go/answer", `{"Answer": ":="}`, nil); code != http.StatusTooManyRequests {
+		t.Errorf("answering in the cooldown: status %d, want %d", code, http.StatusTooManyRequests)
+	}
+	var hints hintResponse
+	if code := do("POST", "/riddles/go/hint", "", &hints); code != http.StatusOK || len(hints.Hints) != 1 {
+		t.Errorf("hint: status %d, %+v", code, hints)
+	}
+
+	var riddle riddleView
+	if code := do("GET", "/riddles/go", "", &riddle); code != http.StatusOK {
+		t.Fatalf("GET /riddles/go: status %d", code)
+	}
+	if riddle.Tries != 1 || riddle.AttemptsLeft != 2 || riddle.RetryAt == nil || len(riddle.Hints) != 1 || riddle.HintsLeft != 1 ||
+		riddle.Reward != (game.Balances{GameXP: 3, TechXP: 3}) {
+		t.Errorf("GET /riddles/go = %+v", riddle)
+	}
+	if code := do("GET", "/riddles/go-defer", "", &riddle); code != http.StatusOK || !riddle.Solved || !riddle.Locked {
+		t.Errorf("GET /riddles/go-defer: status %d, %+v", code, riddle)
+	}
+	riddles = nil
+	do("GET", "/riddles", "", &riddles)
+	for _, riddle := range riddles {
+		if riddle.ID == "go-defer" {
+			t.Error("GET /riddles lists a solved riddle")
+		}
+	}
+	if code := do("GET", "/riddles/cobol", "", nil); code != http.StatusNotFound {
+		t.Errorf("unknown riddle: status %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func TestRiddlesAfterBankShrinks(t *testing.T) {
+	g := game.New()
+	for range 2 {
+		if _, err := g.RiddleHint("0xTippi", "go"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filename := filepath.Join(t.TempDir(), "riddles.yaml")
+	bank := `riddles: [{id: go, language: go, prompt: "Declare and assign?", answer: {accept: [":="]}, hints: [Two characters.]}]`
+	if err := os.WriteFile(filename, []byte(bank), 0644); err != nil {
+		t.Fatal(err)
+	}
+	smaller, err := game.LoadRiddleBank(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetRiddleBank(smaller)
+	sessions := game.NewSessionStore()
+	session, err := sessions.Create("0xTippi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewMux(g, sessions, game.NewChallengeStore())
+
+	for _, path := range []string{"/riddles", "/riddles/go"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d: %s", path, rec.Code, rec.Body)
+		}
+		if path == "/riddles/go" {
+			var riddle riddleView
+			if err := json.NewDecoder(rec.Body).Decode(&riddle); err != nil {
+				t.Fatal(err)
+			}
+			if len(riddle.Hints) != 1 || riddle.HintsLeft != 0 {
+				t.Errorf("GET %s = %+v, want the one hint the bank has", path, riddle)
+			}
+		}
+	}
+}
+
+func TestPermissionDenied(t *testing.T) {
+	g := game.New()
+	g.AddPlayer("0xPlain", "Plain")
+	g.AddPlayer("0xOther", "Other")
+	g.RemovePlayer("0xTippi", "0xOther", "testing")
+	g.AddPlayer("0xGM", "Gamemaster")
+	g.SetRole("0xGM", game.RoleGamemaster)
+	sessions := game.NewSessionStore()
+	mux := NewMux(g, sessions, game.NewChallengeStore())
+
+	tests := []struct {
+		wallet, method, path, body string
+	}{
+		{"0xPlain", "POST", "/players", `{"WalletAddress": "0xNew", "PlayerName": "New"}`},
+		{"0xPlain", "DELETE", "/players/0xTippi", ""},
+		{"0xPlain", "POST", "/players/0xPlain/awards", `{"GameTokens": 100}`},
+		{"0xPlain", "PUT", "/players/0xPlain/role", `{"Role": "admin"}`},
+		{"0xPlain", "GET", "/purgatory", ""},
+		{"0xPlain", "POST", "/purgatory/0xOther/restore", ""},
+		{"0xPlain", "PUT", "/purgatory/0xOther/appeal", `{"Appeal": "please"}`},
+		{"0xPlain", "POST", "/save", ""},
+		{"0xPlain", "POST", "/load", ""},
+		// Gamemasters may remove players, but not admins.
+		{"0xGM", "DELETE", "/players/0xTippi", ""},
+		// Trading is for players, not guests.
+		{"0xGuest", "POST", "/transfers", `{"To": "0xPlain", "Token": "game", "Amount": 1}`},
+		{"0xGuest", "POST", "/offers", ""},
+		{"0xGuest", "POST", "/offers/1/accept", ""},
+		{"0xGuest", "DELETE", "/offers/1", ""},
+	}
+	for _, tt := range tests {
+		session, err := sessions.Create(tt.wallet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s as %s: status %d, want %d", tt.method, tt.path, tt.wallet, rec.Code, http.StatusForbidden)
+		}
+	}
+	if role := g.RoleOf("0xPlain"); role != game.RolePlayer {
+		t.Errorf("0xPlain made themselves %s", role)
+	}
+	if _, ok := g.Player("0xTippi"); !ok || len(g.PurgatoryPlayers()) != 1 {
+		t.Error("a forbidden request changed the players")
+	}
+}
+
+func TestOversizedAnswer(t *testing.T) {
+	g := game.New()
+	sessions := game.NewSessionStore()
+	session, err := sessions.Create("0xTippi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewMux(g, sessions, game.NewChallengeStore())
+
+	body := `{"Answer": "` + strings.Repeat("x", maxBodyBytes) + `"}`
+	req := httptest.NewRequest("POST", "/riddles/go-tally/answer", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized answer: status %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if attempt := g.RiddleAttempt("0xTippi", "go-tally"); attempt.Tries != 0 {
+		t.Errorf("oversized answer was counted: %+v", attempt)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	saveDir = filepath.Join(t.TempDir(), "saves")
+	defer func() { saveDir = "saves" }()
+	g := game.New()
+	sessions := game.NewSessionStore()
+	session, err := sessions.Create("0xTippi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := NewMux(g, sessions, game.NewChallengeStore())
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+session.Token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("/save", `{"Filename": "backup"}`); rec.Code != http.StatusOK {
+		t.Fatalf("save: status %d: %s", rec.Code, rec.Body)
+	}
+	if _, err := os.Stat(filepath.Join(saveDir, "backup")); err != nil {
+		t.Errorf("save didn't land in the saves directory: %v", err)
+	}
+	if rec := post("/load", `{"Filename": "backup"}`); rec.Code != http.StatusOK {
+		t.Errorf("load: status %d: %s", rec.Code, rec.Body)
+	}
+	for _, name := range []string{"../go.mod", ".hidden", ""} {
+		if rec := post("/save", `{"Filename": "`+name+`"}`); rec.Code != http.StatusBadRequest {
+			t.Errorf("save %q: status %d, want %d", name, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestInternalErrorHidden(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeGameError(rec, errors.New("open /srv/ceptor/ceptor.db: permission denied"))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "ceptor.db") {
+		t.Errorf("internal error: status %d: %s", rec.Code, rec.Body)
+	}
+}
+
+// TestOpenAPIMatchesRoutes checks that openapi.json describes exactly the
+// routes NewMux registers.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	var documented []string
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+
+	// The routes are read from the source, since a ServeMux can't list them.
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "server.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var registered []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "HandleFunc" {
+			return true
+		}
+		if lit, ok := call.Args[0].(*ast.BasicLit); ok {
+			pattern, _ := strconv.Unquote(lit.Value)
+			registered = append(registered, strings.TrimSuffix(pattern, "{$}"))
+		}
+		return true
+	})
+
+	slices.Sort(documented)
+	slices.Sort(registered)
+	for _, route := range documented {
+		if !slices.Contains(registered, route) {
+			t.Errorf("openapi.json documents %s, which is not registered", route)
+		}
+	}
+	for _, route := range registered {
+		if !slices.Contains(documented, route) {
+			t.Errorf("%s is registered but not in openapi.json", route)
+		}
+	}
+
+	mux := NewMux(game.New(), game.NewSessionStore(), game.NewChallengeStore())
+	for _, route := range documented {
+		method, path, _ := strings.Cut(route, " ")
+		_, pattern := mux.Handler(httptest.NewRequest(method, strings.NewReplacer("{", "", "}", "").Replace(path), nil))
+		if strings.TrimSuffix(pattern, "{$}") != route {
+			t.Errorf("%s is served by %q", route, pattern)
+		}
+	}
+}
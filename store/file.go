@@ -0,0 +1,162 @@
+// Package store has the game.Store implementations: a JSON file in the same
+// versioned format as Game.Save (older saves such as disco are upgraded on
+// open) and an embedded SQLite database.
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// File keeps the whole game in one JSON file and rewrites it on every change.
+type File struct {
+	mu       sync.Mutex
+	path     string
+	snapshot *game.Snapshot
+}
+
+// OpenFile opens the JSON save file at path, starting empty if it doesn't exist yet.
+func OpenFile(path string) (*File, error) {
+	f := &File{path: path, snapshot: game.NewSnapshot()}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.snapshot, err = game.DecodeSave(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Path returns the file the game is kept in.
+func (f *File) Path() string {
+	return f.path
+}
+
+func (f *File) Load() (*game.Snapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return cloneSnapshot(f.snapshot), nil
+}
+
+func (f *File) GetPlayer(walletAddress string) (*game.Player, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	player, exists := f.snapshot.Players[walletAddress]
+	if !exists {
+		return nil, game.ErrPlayerNotFound
+	}
+	return player.Clone(), nil
+}
+
+func (f *File) PutPlayer(player *game.Player) error {
+	return f.update(func(s *game.Snapshot) { s.Players[player.WalletAddress] = player.Clone() })
+}
+
+func (f *File) DeletePlayer(walletAddress string) error {
+	return f.update(func(s *game.Snapshot) { delete(s.Players, walletAddress) })
+}
+
+func (f *File) SetAllowed(walletAddress string, allowed bool) error {
+	return f.update(func(s *game.Snapshot) {
+		if allowed {
+			s.AllowList[walletAddress] = true
+		} else {
+			delete(s.AllowList, walletAddress)
+		}
+	})
+}
+
+func (f *File) PutPurgatory(player *game.Player) error {
+	return f.update(func(s *game.Snapshot) { s.Purgatory[player.WalletAddress] = player.Clone() })
+}
+
+func (f *File) DeletePurgatory(walletAddress string) error {
+	return f.update(func(s *game.Snapshot) { delete(s.Purgatory, walletAddress) })
+}
+
+func (f *File) AppendLedger(entries ...game.LedgerEntry) error {
+	return f.update(func(s *game.Snapshot) { s.Ledger = append(s.Ledger, entries...) })
+}
+
+func (f *File) PutOffer(offer *game.Offer, entries ...game.LedgerEntry) error {
+	return f.update(func(s *game.Snapshot) {
+		stored := *offer
+		s.Offers[offer.ID] = &stored
+		s.Ledger = append(s.Ledger, entries...)
+	})
+}
+
+func (f *File) Replace(snapshot *game.Snapshot) error {
+	return f.update(func(s *game.Snapshot) { *s = *cloneSnapshot(snapshot) })
+}
+
+func (f *File) Close() error {
+	return nil
+}
+
+// update applies change to a copy of the snapshot and keeps it only once
+// the file has been written.
+func (f *File) update(change func(s *game.Snapshot)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	next := cloneSnapshot(f.snapshot)
+	change(next)
+	if err := writeFileAtomic(f.path, next); err != nil {
+		return err
+	}
+	f.snapshot = next
+	return nil
+}
+
+// writeFileAtomic writes to a temporary file and renames it over path, so a
+// crash never leaves a half-written save behind.
+func writeFileAtomic(path string, snapshot *game.Snapshot) error {
+	data, err := game.EncodeSave(snapshot, time.Now())
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func cloneSnapshot(s *game.Snapshot) *game.Snapshot {
+	c := game.NewSnapshot()
+	for walletAddress, player := range s.Players {
+		c.Players[walletAddress] = player.Clone()
+	}
+	for walletAddress, allowed := range s.AllowList {
+		c.AllowList[walletAddress] = allowed
+	}
+	for walletAddress, player := range s.Purgatory {
+		c.Purgatory[walletAddress] = player.Clone()
+	}
+	c.Ledger = append([]game.LedgerEntry(nil), s.Ledger...)
+	for id, offer := range s.Offers {
+		stored := *offer
+		c.Offers[id] = &stored
+	}
+	return c
+}
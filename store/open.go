@@ -0,0 +1,16 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// Open picks a store from a command line spec: "sqlite:ceptor.db" for
+// SQLite, anything else ("disco", "file:ceptor.json") for a JSON file.
+func Open(spec string) (game.Store, error) {
+	if path, ok := strings.CutPrefix(spec, "sqlite:"); ok {
+		return OpenSQLite(path)
+	}
+	return OpenFile(strings.TrimPrefix(spec, "file:"))
+}
@@ -0,0 +1,364 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // Registers the "sqlite3" driver
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// migrations upgrade the schema one step at a time. The database's
+// PRAGMA user_version records how many have run, so only append here.
+var migrations = []string{
+	// 1: players, allow list, Purgatory and awards.
+	`CREATE TABLE players (
+		wallet TEXT PRIMARY KEY,
+		data   TEXT NOT NULL
+	);
+	CREATE TABLE allowlist (
+		wallet TEXT PRIMARY KEY
+	);
+	CREATE TABLE purgatory (
+		wallet TEXT PRIMARY KEY,
+		data   TEXT NOT NULL
+	);
+	CREATE TABLE awards (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		wallet      TEXT NOT NULL,
+		game_tokens INTEGER NOT NULL,
+		art_tokens  INTEGER NOT NULL,
+		tech_tokens INTEGER NOT NULL,
+		art_xp      INTEGER NOT NULL,
+		game_xp     INTEGER NOT NULL,
+		tech_xp     INTEGER NOT NULL,
+		created_at  TEXT NOT NULL
+	);
+	CREATE INDEX awards_wallet ON awards (wallet);`,
+
+	// 2: the award table becomes the ledger. Balances that no award explains
+	// are carried over as an undated opening balance.
+	`ALTER TABLE awards RENAME TO ledger;
+	ALTER TABLE ledger ADD COLUMN actor TEXT NOT NULL DEFAULT '';
+	ALTER TABLE ledger ADD COLUMN reason TEXT NOT NULL DEFAULT '';
+	INSERT INTO ledger (` + ledgerColumns + `)
+	SELECT 'system', wallet, game_tokens, art_tokens, tech_tokens, art_xp, game_xp, tech_xp, 'opening balance', '0001-01-01T00:00:00Z'
+	FROM (
+		SELECT p.wallet,
+			COALESCE(json_extract(p.data, '$.GameTokens'), 0) - COALESCE(SUM(l.game_tokens), 0) AS game_tokens,
+			COALESCE(json_extract(p.data, '$.ArtTokens'), 0) - COALESCE(SUM(l.art_tokens), 0) AS art_tokens,
+			COALESCE(json_extract(p.data, '$.TechTokens'), 0) - COALESCE(SUM(l.tech_tokens), 0) AS tech_tokens,
+			COALESCE(json_extract(p.data, '$.ArtXP'), 0) - COALESCE(SUM(l.art_xp), 0) AS art_xp,
+			COALESCE(json_extract(p.data, '$.GameXP'), 0) - COALESCE(SUM(l.game_xp), 0) AS game_xp,
+			COALESCE(json_extract(p.data, '$.TechXP'), 0) - COALESCE(SUM(l.tech_xp), 0) AS tech_xp
+		FROM (SELECT wallet, data FROM players UNION ALL SELECT wallet, data FROM purgatory) p
+		LEFT JOIN ledger l ON l.wallet = p.wallet
+		GROUP BY p.wallet, p.data
+	)
+	WHERE game_tokens != 0 OR art_tokens != 0 OR tech_tokens != 0 OR art_xp != 0 OR game_xp != 0 OR tech_xp != 0;`,
+
+	// 3: the marketplace.
+	`CREATE TABLE offers (
+		id   INTEGER PRIMARY KEY,
+		data TEXT NOT NULL
+	);`,
+}
+
+// SQLite keeps the game in an embedded SQLite database. Players are stored
+// as JSON, so new Player fields don't need a migration.
+type SQLite struct {
+	db   *sql.DB
+	path string
+}
+
+// OpenSQLite opens (or creates) the database at path and runs any pending migrations.
+func OpenSQLite(path string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // SQLite allows one writer; serialize rather than hit SQLITE_BUSY
+	s := &SQLite{db: db, path: path}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path returns the database file.
+func (s *SQLite) Path() string {
+	return s.path
+}
+
+func (s *SQLite) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLite) Load() (*game.Snapshot, error) {
+	snapshot := game.NewSnapshot()
+	if err := s.loadPlayers("SELECT data FROM players", snapshot.Players); err != nil {
+		return nil, err
+	}
+	if err := s.loadPlayers("SELECT data FROM purgatory", snapshot.Purgatory); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query("SELECT wallet FROM allowlist")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var walletAddress string
+		if err := rows.Scan(&walletAddress); err != nil {
+			return nil, err
+		}
+		snapshot.AllowList[walletAddress] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if snapshot.Ledger, err = s.queryLedger("SELECT " + ledgerColumns + " FROM ledger ORDER BY id"); err != nil {
+		return nil, err
+	}
+	if err := s.loadOffers(snapshot.Offers); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (s *SQLite) loadOffers(into map[int]*game.Offer) error {
+	rows, err := s.db.Query("SELECT data FROM offers")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		var offer game.Offer
+		if err := json.Unmarshal([]byte(data), &offer); err != nil {
+			return err
+		}
+		into[offer.ID] = &offer
+	}
+	return rows.Err()
+}
+
+func (s *SQLite) loadPlayers(query string, into map[string]*game.Player) error {
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		var player game.Player
+		if err := json.Unmarshal([]byte(data), &player); err != nil {
+			return err
+		}
+		into[player.WalletAddress] = &player
+	}
+	return rows.Err()
+}
+
+func (s *SQLite) GetPlayer(walletAddress string) (*game.Player, error) {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM players WHERE wallet = ?", walletAddress).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, game.ErrPlayerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var player game.Player
+	if err := json.Unmarshal([]byte(data), &player); err != nil {
+		return nil, err
+	}
+	return &player, nil
+}
+
+func (s *SQLite) PutPlayer(player *game.Player) error {
+	return putPlayer(s.db, "players", player)
+}
+
+func (s *SQLite) DeletePlayer(walletAddress string) error {
+	_, err := s.db.Exec("DELETE FROM players WHERE wallet = ?", walletAddress)
+	return err
+}
+
+func (s *SQLite) SetAllowed(walletAddress string, allowed bool) error {
+	var err error
+	if allowed {
+		_, err = s.db.Exec("INSERT OR IGNORE INTO allowlist (wallet) VALUES (?)", walletAddress)
+	} else {
+		_, err = s.db.Exec("DELETE FROM allowlist WHERE wallet = ?", walletAddress)
+	}
+	return err
+}
+
+func (s *SQLite) PutPurgatory(player *game.Player) error {
+	return putPlayer(s.db, "purgatory", player)
+}
+
+func (s *SQLite) DeletePurgatory(walletAddress string) error {
+	_, err := s.db.Exec("DELETE FROM purgatory WHERE wallet = ?", walletAddress)
+	return err
+}
+
+func (s *SQLite) AppendLedger(entries ...game.LedgerEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, entry := range entries {
+		if err := insertLedger(tx, entry); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) PutOffer(offer *game.Offer, entries ...game.LedgerEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := putOffer(tx, offer); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := insertLedger(tx, entry); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) Replace(snapshot *game.Snapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"players", "allowlist", "purgatory", "ledger", "offers"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return err
+		}
+	}
+	for _, player := range snapshot.Players {
+		if err := putPlayer(tx, "players", player); err != nil {
+			return err
+		}
+	}
+	for _, player := range snapshot.Purgatory {
+		if err := putPlayer(tx, "purgatory", player); err != nil {
+			return err
+		}
+	}
+	for walletAddress := range snapshot.AllowList {
+		if _, err := tx.Exec("INSERT INTO allowlist (wallet) VALUES (?)", walletAddress); err != nil {
+			return err
+		}
+	}
+	for _, entry := range snapshot.Ledger {
+		if err := insertLedger(tx, entry); err != nil {
+			return err
+		}
+	}
+	for _, offer := range snapshot.Offers {
+		if err := putOffer(tx, offer); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// execer is what *sql.DB and *sql.Tx have in common.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func putPlayer(db execer, table string, player *game.Player) error {
+	data, err := json.Marshal(player)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT INTO "+table+" (wallet, data) VALUES (?, ?) ON CONFLICT (wallet) DO UPDATE SET data = excluded.data", player.WalletAddress, string(data))
+	return err
+}
+
+func putOffer(db execer, offer *game.Offer) error {
+	data, err := json.Marshal(offer)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("INSERT INTO offers (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data", offer.ID, string(data))
+	return err
+}
+
+const ledgerColumns = "actor, wallet, game_tokens, art_tokens, tech_tokens, art_xp, game_xp, tech_xp, reason, created_at"
+
+func insertLedger(db execer, entry game.LedgerEntry) error {
+	_, err := db.Exec("INSERT INTO ledger ("+ledgerColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		entry.Actor, entry.WalletAddress, entry.GameTokens, entry.ArtTokens, entry.TechTokens,
+		entry.ArtXP, entry.GameXP, entry.TechXP, entry.Reason, entry.Time.UTC().Format(time.RFC3339Nano))
+	return err
+}
+
+func (s *SQLite) queryLedger(query string, args ...interface{}) ([]game.LedgerEntry, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []game.LedgerEntry
+	for rows.Next() {
+		var entry game.LedgerEntry
+		var createdAt string
+		if err := rows.Scan(&entry.Actor, &entry.WalletAddress, &entry.GameTokens, &entry.ArtTokens, &entry.TechTokens,
+			&entry.ArtXP, &entry.GameXP, &entry.TechXP, &entry.Reason, &createdAt); err != nil {
+			return nil, err
+		}
+		if entry.Time, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
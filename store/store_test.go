@@ -0,0 +1,239 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tippi-fifestarr/go-ceptor/game"
+)
+
+// testStore runs the same write-through scenario against any Store, then
+// reopens it with reopen to check that everything was persisted.
+func testStore(t *testing.T, s game.Store, reopen func() game.Store) {
+	g, err := game.Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddPlayer("0xDisco", "Discordian"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AddPlayer("0xGone", "Gone"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.AwardTokensXP("0xTippi", "0xDisco", game.Balances{GameTokens: 1, ArtTokens: 2, TechTokens: 3, ArtXP: 4, GameXP: 5, TechXP: 6}, "quest"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePlayer("0xTippi", "0xGone", "left the club"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.PostOffer("0xDisco", game.TokenGame, 2, game.TokenArt, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s = reopen()
+	defer s.Close()
+	snapshot, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	disco, ok := snapshot.Players["0xDisco"]
+	if !ok {
+		t.Fatal("0xDisco was not persisted")
+	}
+	if disco.GameTokens != 4 || disco.TechXP != 6 {
+		t.Errorf("0xDisco balances not persisted: %+v", disco)
+	}
+	if _, ok := snapshot.Players["0xTippi"]; !ok {
+		t.Error("empty store was not seeded with Tippi")
+	}
+	if !snapshot.AllowList["0xDisco"] || snapshot.AllowList["0xGone"] {
+		t.Errorf("allow list = %v", snapshot.AllowList)
+	}
+	if gone, ok := snapshot.Purgatory["0xGone"]; !ok {
+		t.Error("0xGone was not moved to Purgatory")
+	} else if gone.Removal == nil || gone.Removal.Reason != "left the club" || gone.Removal.RemovedBy != "0xTippi" {
+		t.Errorf("0xGone removal = %+v", gone.Removal)
+	}
+	var awards []game.LedgerEntry
+	for _, entry := range snapshot.Ledger {
+		if entry.WalletAddress == "0xDisco" {
+			awards = append(awards, entry)
+		}
+	}
+	if offer := snapshot.Offers[1]; offer == nil || offer.Seller != "0xDisco" || offer.Status != game.OfferOpen {
+		t.Errorf("offers = %+v", snapshot.Offers)
+	}
+	// The starting balance, the award, then the escrow for the offer.
+	if len(awards) != 3 || awards[1].ArtTokens != 2 || awards[1].Actor != "0xTippi" || awards[1].Reason != "quest" {
+		t.Errorf("ledger = %+v", awards)
+	}
+	if got := game.Replay(snapshot.Ledger)["0xDisco"]; got != disco.Balances {
+		t.Errorf("replayed balances %+v, stored %+v", got, disco.Balances)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "save.json")
+	open := func() game.Store {
+		s, err := OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	testStore(t, open(), open)
+}
+
+func TestSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceptor.db")
+	open := func() game.Store {
+		s, err := OpenSQLite(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	testStore(t, open(), open)
+}
+
+func TestFileReadsDiscoSave(t *testing.T) {
+	data, err := os.ReadFile("../disco")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "disco")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := game.Open(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disco, ok := g.Player("0xDisco")
+	if !ok || disco.TechXP != 100 {
+		t.Errorf("0xDisco = %+v, %v", disco, ok)
+	}
+	if !g.IsAllowed("0xTippi") {
+		t.Error("0xTippi should be on the allow list")
+	}
+}
+
+// TestSaveRefusesStore checks that Save won't write over the file a store
+// is using, and still saves anywhere else.
+func TestSaveRefusesStore(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name string
+		open func(path string) (game.Store, error)
+	}{
+		{"file", func(path string) (game.Store, error) { return OpenFile(path) }},
+		{"sqlite", func(path string) (game.Store, error) { return OpenSQLite(path) }},
+	} {
+		path := filepath.Join(dir, tt.name)
+		s, err := tt.open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := game.Open(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer g.Close()
+		if err := g.Save(filepath.Join(dir, ".", tt.name)); !errors.Is(err, game.ErrSaveOverStore) {
+			t.Errorf("%s: saving over the store: %v, want %v", tt.name, err, game.ErrSaveOverStore)
+		}
+		if err := g.Save(path + ".bak"); err != nil {
+			t.Errorf("%s: saving elsewhere: %v", tt.name, err)
+		}
+	}
+}
+
+// TestOpenGrantsEnvAdmin opens a save from before the admin wallet was
+// set, which seeding alone would never make an admin.
+func TestOpenGrantsEnvAdmin(t *testing.T) {
+	data, err := os.ReadFile("../disco")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "disco")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	open := func(adminWallet string) *game.Game {
+		t.Setenv("CEPTOR_ADMIN_WALLET", adminWallet)
+		s, err := OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := game.Open(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+
+	g := open("0xReal")
+	if !g.IsAllowed("0xReal") || g.RoleOf("0xReal") != game.RoleAdmin {
+		t.Errorf("new env admin: allowed %v, role %s", g.IsAllowed("0xReal"), g.RoleOf("0xReal"))
+	}
+	g.Close()
+	g = open("0xDisco")
+	disco, _ := g.Player("0xDisco")
+	if g.RoleOf("0xDisco") != game.RoleAdmin || disco.TechXP != 100 {
+		t.Errorf("existing player as env admin = %+v", disco)
+	}
+	g.Close()
+	g = open("")
+	defer g.Close()
+	if g.RoleOf("0xReal") != game.RoleAdmin || g.RoleOf("0xDisco") != game.RoleAdmin {
+		t.Error("env admins were not persisted")
+	}
+}
+
+// TestSQLiteLedgerMigration upgrades a version 1 database, whose players
+// have balances that only partly come from recorded awards.
+func TestSQLiteLedgerMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v1.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		migrations[0],
+		"PRAGMA user_version = 1",
+		`INSERT INTO players (wallet, data) VALUES ('0xA', '{"WalletAddress":"0xA","GameTokens":7,"TechXP":3}')`,
+		`INSERT INTO awards (wallet, game_tokens, art_tokens, tech_tokens, art_xp, game_xp, tech_xp, created_at)
+			VALUES ('0xA', 2, 0, 0, 0, 0, 3, '2024-01-01T00:00:00Z')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	snapshot, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Ledger) != 2 {
+		t.Fatalf("ledger = %+v, want the award and an opening balance", snapshot.Ledger)
+	}
+	if got := game.Replay(snapshot.Ledger)["0xA"]; got != (game.Balances{GameTokens: 7, TechXP: 3}) {
+		t.Errorf("replayed balances = %+v", got)
+	}
+}